@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key, so they can be told apart from tokens and spotted if leaked
+const APIKeyPrefix = "cgk_"
+
+// apiKeyDisplayLength is how much of a key is kept in the clear to help users recognise it
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// NewAPIKey returns a random API key to show the user once, along with its display prefix and the hash to store
+func NewAPIKey() (string, string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:apiKeyDisplayLength], HashToken(key), nil
+}
+
+// IsAPIKey reports whether a credential is an API key rather than a token
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+type contextKey struct{}
+
+// ContextWithClaims returns a copy of ctx carrying the claims of the token a request was authorized with
+func ContextWithClaims(ctx context.Context, claims jwt.MapClaims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the token a request was authorized with, or nil if there are none
+func ClaimsFromContext(ctx context.Context) jwt.MapClaims {
+	claims, _ := ctx.Value(contextKey{}).(jwt.MapClaims)
+	return claims
+}
+
+// TokenID returns the jti claim identifying a token
+func TokenID(claims jwt.MapClaims) string {
+	jti, _ := claims["jti"].(string)
+	return jti
+}
+
+// IssuedAt returns when a token was issued
+func IssuedAt(claims jwt.MapClaims) time.Time {
+	iat, _ := claims["iat"].(float64)
+	seconds, fraction := math.Modf(iat)
+	return time.Unix(int64(seconds), int64(fraction*float64(time.Second)))
+}
+
+// ExpiresAt returns when a token expires
+func ExpiresAt(claims jwt.MapClaims) time.Time {
+	exp, _ := claims["exp"].(float64)
+	return time.Unix(int64(exp), 0)
+}
+
+type organizationKey struct{}
+
+// ContextWithOrganization returns a copy of ctx carrying the organization a request acts on behalf of
+func ContextWithOrganization(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, organizationKey{}, orgID)
+}
+
+// OrganizationFromContext returns the organization a request acts on behalf of, or an empty string for the user's own resources
+func OrganizationFromContext(ctx context.Context) string {
+	orgID, _ := ctx.Value(organizationKey{}).(string)
+	return orgID
+}
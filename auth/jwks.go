@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+)
+
+// JSONWebKey is the public part of a key, as described by RFC 7517
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a list of public keys which verify tokens
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// FromJSON converts data from JSON
+func (s *JSONWebKeySet) FromJSON(data io.Reader) error {
+	de := json.NewDecoder(data)
+	return de.Decode(s)
+}
+
+// ToJSON converts data to JSON
+func (s *JSONWebKeySet) ToJSON() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// JWKS returns the public keys of every asymmetric key in the set. HMAC secrets are never published.
+func (ks *KeySet) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+
+	for _, id := range ks.order {
+		k := ks.keys[id]
+
+		switch public := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				KeyType:   "RSA",
+				KeyID:     k.id,
+				Use:       "sig",
+				Algorithm: k.method.Alg(),
+				N:         encodeBase64(public.N.Bytes()),
+				E:         encodeBase64(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			set.Keys = append(set.Keys, JSONWebKey{
+				KeyType:   "EC",
+				KeyID:     k.id,
+				Use:       "sig",
+				Algorithm: k.method.Alg(),
+				Curve:     public.Curve.Params().Name,
+				X:         encodeBase64(padBytes(public.X.Bytes(), size)),
+				Y:         encodeBase64(padBytes(public.Y.Bytes(), size)),
+			})
+		}
+	}
+
+	return set
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// padBytes left pads b with zeros to size, as EC coordinates have a fixed length
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
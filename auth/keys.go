@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/config"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultTokenTTL is how long access tokens are valid for when token_ttl is not set
+	defaultTokenTTL = 15 * time.Minute
+	// defaultRefreshTokenTTL is how long refresh tokens are valid for when refresh_token_ttl is not set
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// ErrUnknownKey is returned when a token names a key ID which is not in the key set
+var ErrUnknownKey = errors.New("Unknown signing key")
+
+// KeyConfig describes a key used to sign or verify tokens.
+// HS256 keys take a secret, either directly or from the environment variable named by secret_env.
+// RS256 and ES256 keys take a PEM encoded private key, or only a public key if they are kept for verification.
+type KeyConfig struct {
+	ID             string `json:"id"`
+	Algorithm      string `json:"algorithm"`
+	Secret         string `json:"secret"`
+	SecretEnv      string `json:"secret_env"`
+	PrivateKeyFile string `json:"private_key_file"`
+	PublicKeyFile  string `json:"public_key_file"`
+}
+
+// Config contains the keys used for tokens, and which of them signs new tokens
+type Config struct {
+	SigningKeyID    string          `json:"signing_key_id"`
+	TokenTTL        config.Duration `json:"token_ttl"`
+	RefreshTokenTTL config.Duration `json:"refresh_token_ttl"`
+	Keys            []KeyConfig     `json:"keys"`
+}
+
+// key is a loaded KeyConfig
+type key struct {
+	id         string
+	method     jwt.SigningMethod
+	signingKey interface{}
+	verifyKey  interface{}
+}
+
+// KeySet signs tokens with the current signing key and verifies them with any active key,
+// so keys can be rotated without invalidating tokens which are still in use
+type KeySet struct {
+	keys       map[string]*key
+	order      []string
+	signing    *key
+	ttl        time.Duration
+	refreshTTL time.Duration
+}
+
+// NewKeySet loads the keys in config
+func NewKeySet(config Config) (*KeySet, error) {
+	ks := &KeySet{keys: map[string]*key{}, ttl: config.TokenTTL.Duration, refreshTTL: config.RefreshTokenTTL.Duration}
+	if ks.ttl <= 0 {
+		ks.ttl = defaultTokenTTL
+	}
+	if ks.refreshTTL <= 0 {
+		ks.refreshTTL = defaultRefreshTokenTTL
+	}
+
+	for _, kc := range config.Keys {
+		if kc.ID == "" {
+			return nil, fmt.Errorf("key is missing an id")
+		}
+		if _, ok := ks.keys[kc.ID]; ok {
+			return nil, fmt.Errorf("key %s is configured more than once", kc.ID)
+		}
+
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load key %s: %s", kc.ID, err)
+		}
+
+		ks.keys[k.id] = k
+		ks.order = append(ks.order, k.id)
+	}
+
+	signing, ok := ks.keys[config.SigningKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not configured", config.SigningKeyID)
+	}
+	if signing.signingKey == nil {
+		return nil, fmt.Errorf("signing key %s has no private key", config.SigningKeyID)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+func loadKey(kc KeyConfig) (*key, error) {
+	k := &key{id: kc.ID, method: jwt.GetSigningMethod(kc.Algorithm)}
+
+	switch kc.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		secret := kc.Secret
+		if kc.SecretEnv != "" {
+			secret = os.Getenv(kc.SecretEnv)
+			if secret == "" {
+				return nil, fmt.Errorf("%s is not set", kc.SecretEnv)
+			}
+		}
+		if secret == "" {
+			return nil, fmt.Errorf("no secret set, set secret or the environment variable named by secret_env")
+		}
+		k.signingKey = []byte(secret)
+		k.verifyKey = []byte(secret)
+
+	case jwt.SigningMethodRS256.Alg():
+		if kc.PrivateKeyFile != "" {
+			d, err := ioutil.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(d)
+			if err != nil {
+				return nil, err
+			}
+			k.signingKey = private
+			k.verifyKey = &private.PublicKey
+		} else {
+			d, err := readPublicKey(kc)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(d)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = public
+		}
+
+	case jwt.SigningMethodES256.Alg():
+		if kc.PrivateKeyFile != "" {
+			d, err := ioutil.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseECPrivateKeyFromPEM(d)
+			if err != nil {
+				return nil, err
+			}
+			k.signingKey = private
+			k.verifyKey = &private.PublicKey
+		} else {
+			d, err := readPublicKey(kc)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseECPublicKeyFromPEM(d)
+			if err != nil {
+				return nil, err
+			}
+			k.verifyKey = public
+		}
+		if k.verifyKey.(*ecdsa.PublicKey).Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ES256 keys must use the P-256 curve")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s, use HS256, RS256 or ES256", kc.Algorithm)
+	}
+
+	return k, nil
+}
+
+func readPublicKey(kc KeyConfig) ([]byte, error) {
+	if kc.PublicKeyFile == "" {
+		return nil, fmt.Errorf("%s keys need a private_key_file or public_key_file", kc.Algorithm)
+	}
+	return ioutil.ReadFile(kc.PublicKeyFile)
+}
+
+// TokenTTL is how long access tokens are valid for
+func (ks *KeySet) TokenTTL() time.Duration {
+	return ks.ttl
+}
+
+// RefreshTokenTTL is how long refresh tokens are valid for
+func (ks *KeySet) RefreshTokenTTL() time.Duration {
+	return ks.refreshTTL
+}
+
+// Sign returns a token containing claims, signed with the current signing key and expiring after the token TTL.
+// Every token is given a unique jti so it can be revoked, and its iat has sub-second precision so it can be compared
+// with when a user's tokens were revoked.
+func (ks *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	issued := time.Now()
+
+	signed := jwt.MapClaims{
+		"jti": uuid.New().String(),
+		"iat": float64(issued.UnixNano()) / float64(time.Second),
+		"exp": issued.Add(ks.ttl).Unix(),
+	}
+	for k, v := range claims {
+		signed[k] = v
+	}
+
+	token := jwt.NewWithClaims(ks.signing.method, signed)
+	token.Header["kid"] = ks.signing.id
+
+	return token.SignedString(ks.signing.signingKey)
+}
+
+// Parse verifies a token with the key named by its kid header and returns its claims
+func (ks *KeySet) Parse(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		k, ok := ks.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s for key %s", token.Method.Alg(), kid)
+		}
+
+		return k.verifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewKeySetSecretEnv(t *testing.T) {
+	config := Config{
+		SigningKeyID: "key-1",
+		Keys:         []KeyConfig{{ID: "key-1", Algorithm: "HS256", SecretEnv: "CLOUDYGO_TEST_SECRET"}},
+	}
+
+	defer os.Unsetenv("CLOUDYGO_TEST_SECRET")
+
+	os.Setenv("CLOUDYGO_TEST_SECRET", "")
+	_, err := NewKeySet(config)
+	if err == nil || !strings.Contains(err.Error(), "CLOUDYGO_TEST_SECRET is not set") {
+		t.Errorf("error with the secret unset = %v, want CLOUDYGO_TEST_SECRET is not set", err)
+	}
+
+	os.Setenv("CLOUDYGO_TEST_SECRET", "s3cret")
+	if _, err := NewKeySet(config); err != nil {
+		t.Errorf("error with the secret set = %v, want nil", err)
+	}
+}
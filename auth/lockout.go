@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/config"
+)
+
+const (
+	// defaultLockoutWindow is how long failed sign ins are counted for when window is not set
+	defaultLockoutWindow = 15 * time.Minute
+	// defaultLockout is how long the first lockout lasts when lockout is not set
+	defaultLockout = time.Minute
+	// defaultMaxLockout is the longest a lockout can last when max_lockout is not set
+	defaultMaxLockout = time.Hour
+)
+
+// LockoutConfig controls how sign ins are locked out after repeated failures. Failures are counted separately
+// per username and per IP address. Once either reaches its maximum, further attempts are locked out, and each
+// failure after a lockout ends doubles the next one. Setting a maximum to 0 disables that lockout.
+type LockoutConfig struct {
+	MaxUsernameFailures int             `json:"max_username_failures"`
+	MaxIPFailures       int             `json:"max_ip_failures"`
+	Window              config.Duration `json:"window"`
+	Lockout             config.Duration `json:"lockout"`
+	MaxLockout          config.Duration `json:"max_lockout"`
+}
+
+// LockoutWindow returns how long failures are counted for after the last one
+func (c *LockoutConfig) LockoutWindow() time.Duration {
+	if c.Window.Duration <= 0 {
+		return defaultLockoutWindow
+	}
+	return c.Window.Duration
+}
+
+// LockoutFor returns how long to lock out sign ins after a number of failures, given how many are allowed
+func (c *LockoutConfig) LockoutFor(failures int, maxFailures int) time.Duration {
+	if maxFailures <= 0 || failures < maxFailures {
+		return 0
+	}
+
+	lockout, limit := c.Lockout.Duration, c.MaxLockout.Duration
+	if lockout <= 0 {
+		lockout = defaultLockout
+	}
+	if limit <= 0 {
+		limit = defaultMaxLockout
+	}
+
+	for i := maxFailures; i < failures && lockout < limit; i++ {
+		lockout *= 2
+	}
+	if lockout > limit {
+		return limit
+	}
+	return lockout
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/config"
+)
+
+func TestLockoutFor(t *testing.T) {
+	conf := LockoutConfig{
+		Lockout:    config.Duration{Duration: time.Minute},
+		MaxLockout: config.Duration{Duration: 10 * time.Minute},
+	}
+
+	tests := []struct {
+		name        string
+		conf        LockoutConfig
+		failures    int
+		maxFailures int
+		want        time.Duration
+	}{
+		{"under the maximum", conf, 4, 5, 0},
+		{"at the maximum", conf, 5, 5, time.Minute},
+		{"doubles after a lockout", conf, 6, 5, 2 * time.Minute},
+		{"doubles again", conf, 7, 5, 4 * time.Minute},
+		{"capped at max lockout", conf, 20, 5, 10 * time.Minute},
+		{"disabled", conf, 100, 0, 0},
+		{"defaults", LockoutConfig{}, 3, 3, defaultLockout},
+		{"default cap", LockoutConfig{}, 100, 3, defaultMaxLockout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.conf.LockoutFor(tt.failures, tt.maxFailures); got != tt.want {
+				t.Errorf("LockoutFor(%d, %d) = %s, want %s", tt.failures, tt.maxFailures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockoutWindow(t *testing.T) {
+	tests := []struct {
+		window time.Duration
+		want   time.Duration
+	}{
+		{0, defaultLockoutWindow},
+		{-time.Second, defaultLockoutWindow},
+		{time.Hour, time.Hour},
+	}
+
+	for _, tt := range tests {
+		conf := LockoutConfig{Window: config.Duration{Duration: tt.window}}
+		if got := conf.LockoutWindow(); got != tt.want {
+			t.Errorf("LockoutWindow() with window %s = %s, want %s", tt.window, got, tt.want)
+		}
+	}
+}
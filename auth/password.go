@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// temporaryPasswordBytes is the amount of randomness in a temporary password
+const temporaryPasswordBytes = 18
+
+// NewTemporaryPassword returns a random password for an admin to hand to a user whose password has been reset
+func NewTemporaryPassword() (string, error) {
+	b := make([]byte, temporaryPasswordBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+const (
+	// ActionRead allows fetching and listing
+	ActionRead = "read"
+	// ActionWrite allows creating, updating and deleting, and implies ActionRead
+	ActionWrite = "write"
+	// Wildcard matches any resource or action in a scope
+	Wildcard = "*"
+)
+
+// FullAccess is the scope granted to users signing in with their password unless they ask for less
+const FullAccess = "*:*"
+
+// ScopeResources are the resources scopes can grant access to
+var ScopeResources = func() []string {
+	resources := []string{}
+	for _, t := range registry.Types() {
+		resources = append(resources, t.Table)
+	}
+	return append(resources, "api_keys", "quotas", "organizations", "users", "audit")
+}()
+
+type scopeKey struct{}
+
+// ContextWithScopes returns a copy of ctx carrying the scopes a request was authorized with
+func ContextWithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scopes)
+}
+
+// ScopesFromContext returns the scopes a request was authorized with
+func ScopesFromContext(ctx context.Context) []string {
+	scopes, _ := ctx.Value(scopeKey{}).([]string)
+	return scopes
+}
+
+func splitScope(scope string) (string, string, bool) {
+	parts := strings.Split(scope, ":")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// ValidateScope checks a scope has the form resource:action, where either part may be a wildcard
+func ValidateScope(scope string) error {
+	resource, action, ok := splitScope(scope)
+	if !ok {
+		return fmt.Errorf("scope %s must have the form resource:action", scope)
+	}
+
+	validResource := resource == Wildcard
+	for _, r := range ScopeResources {
+		validResource = validResource || resource == r
+	}
+	if !validResource {
+		return fmt.Errorf("scope %s has unknown resource %s, use one of %s or %s", scope, resource, strings.Join(ScopeResources, ", "), Wildcard)
+	}
+
+	if action != ActionRead && action != ActionWrite && action != Wildcard {
+		return fmt.Errorf("scope %s has unknown action %s, use %s, %s or %s", scope, action, ActionRead, ActionWrite, Wildcard)
+	}
+
+	return nil
+}
+
+// Allows reports whether any of the granted scopes covers the required scope
+func Allows(granted []string, required string) bool {
+	resource, action, ok := splitScope(required)
+	if !ok {
+		return false
+	}
+
+	for _, g := range granted {
+		grantedResource, grantedAction, ok := splitScope(g)
+		if !ok {
+			continue
+		}
+
+		resourceMatches := grantedResource == Wildcard || grantedResource == resource
+		actionMatches := grantedAction == Wildcard || grantedAction == action ||
+			(grantedAction == ActionWrite && action == ActionRead)
+
+		if resourceMatches && actionMatches {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,57 @@
+package auth
+
+import "testing"
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{"full access", []string{FullAccess}, "lambdas:write", true},
+		{"exact scope", []string{"lambdas:read"}, "lambdas:read", true},
+		{"write implies read", []string{"lambdas:write"}, "lambdas:read", true},
+		{"read does not imply write", []string{"lambdas:read"}, "lambdas:write", false},
+		{"wildcard resource", []string{"*:read"}, "virtual_machines:read", true},
+		{"wildcard resource keeps action", []string{"*:read"}, "virtual_machines:write", false},
+		{"wildcard action", []string{"quotas:*"}, "quotas:write", true},
+		{"other resource", []string{"lambdas:write"}, "sql_databases:read", false},
+		{"any granted scope", []string{"lambdas:read", "audit:read"}, "audit:read", true},
+		{"no scopes", nil, "lambdas:read", false},
+		{"malformed granted scope", []string{"lambdas"}, "lambdas:read", false},
+		{"malformed required scope", []string{FullAccess}, "lambdas", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Allows(tt.granted, tt.required); got != tt.want {
+				t.Errorf("Allows(%v, %q) = %t, want %t", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateScope(t *testing.T) {
+	tests := []struct {
+		scope   string
+		wantErr bool
+	}{
+		{FullAccess, false},
+		{"lambdas:read", false},
+		{"nosql_databases:write", false},
+		{"api_keys:*", false},
+		{"*:read", false},
+		{"lambdas", true},
+		{"lambdas:read:write", true},
+		{"buckets:read", true},
+		{"lambdas:delete", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateScope(tt.scope)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateScope(%q) = %v, want error %t", tt.scope, err, tt.wantErr)
+		}
+	}
+}
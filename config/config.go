@@ -2,29 +2,33 @@ package config
 
 import (
 	"encoding/json"
-	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/danielpadmore/cloudygo-service/logs"
 	"github.com/fsnotify/fsnotify"
 )
 
+// File loads a JSON config file, and loads it again whenever it changes
 type File struct {
-	path       string
-	userConfig interface{}
-	watcher    *fsnotify.Watcher
-	updated    func()
+	logger    logs.Logger
+	path      string
+	newConfig func() interface{}
+	watcher   *fsnotify.Watcher
+	loaded    func(interface{})
 }
 
-func New(fp string, c interface{}, updated func()) (*File, error) {
+// New loads the config file at fp into the pointer returned by newConfig and passes it to loaded, then does the same
+// each time the file changes. Every load decodes into a new value, so a config passed to loaded is never changed
+// afterwards and can be shared with other goroutines. Errors watching or reloading the file are reported to logger.
+func New(logger logs.Logger, fp string, newConfig func() interface{}, loaded func(interface{})) (*File, error) {
 	ap, err := filepath.Abs(fp)
 	if err != nil {
 		return nil, err
 	}
 
-	f := &File{path: ap, userConfig: c, updated: updated}
+	f := &File{logger: logger, path: ap, newConfig: newConfig, loaded: loaded}
 	go f.watch(ap)
 
 	time.Sleep(10 * time.Millisecond)
@@ -35,7 +39,7 @@ func New(fp string, c interface{}, updated func()) (*File, error) {
 func (f *File) Close() {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println("Recovered in f", r)
+			f.logger.Error(newLog("Recovered closing config watcher: %v", r))
 		}
 	}()
 
@@ -49,15 +53,22 @@ func (f *File) loadData() error {
 	}
 	defer cf.Close()
 
+	c := f.newConfig()
+
 	jd := json.NewDecoder(cf)
-	return jd.Decode(f.userConfig)
+	if err := jd.Decode(c); err != nil {
+		return err
+	}
+
+	f.loaded(c)
+	return nil
 }
 
 func (f *File) watch(filepath string) {
 	var err error
 	f.watcher, err = fsnotify.NewWatcher()
 	if err != nil {
-		log.Println("Error", err)
+		f.logger.Error(newLog("Unable to create config watcher: %s", err.Error()))
 	}
 
 	done := make(chan bool)
@@ -75,26 +86,23 @@ func (f *File) watch(filepath string) {
 					event.Op&fsnotify.Chmod == fsnotify.Chmod {
 					err := f.loadData()
 					if err != nil {
-						log.Println("error", err)
-						return
-					}
-
-					if f.updated != nil {
-						f.updated()
+						// keep watching, the file may be part way through being saved
+						f.logger.Error(newLog("Unable to reload config file: %s", err.Error()))
+						continue
 					}
 				}
 			case err, ok := <-f.watcher.Errors:
 				if !ok {
 					return
 				}
-				log.Println("error", err)
+				f.logger.Error(newLog("Error watching config file: %s", err.Error()))
 			}
 		}
 	}()
 
 	err = f.watcher.Add(filepath)
 	if err != nil {
-		log.Println("error", err)
+		f.logger.Error(newLog("Unable to watch config file: %s", err.Error()))
 	}
 	<-done
 }
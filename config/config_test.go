@@ -0,0 +1,53 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/logs"
+)
+
+type testConfig struct {
+	Level string `json:"level"`
+}
+
+func TestFileReloadsAfterInvalidWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fp := filepath.Join(dir, "conf.json")
+	write := func(content string) {
+		if err := ioutil.WriteFile(fp, []byte(content), 0644); err != nil {
+			t.Fatalf("Unable to write config file: %s", err)
+		}
+	}
+	write(`{"level": "info"}`)
+
+	var level atomic.Value
+	f, err := New(logs.NewStdLogger(logs.LogLevelFatal), fp, func() interface{} { return &testConfig{} }, func(c interface{}) {
+		level.Store(c.(*testConfig).Level)
+	})
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+	defer f.Close()
+
+	// a half written file fails to load, which must not stop later changes being loaded
+	write(`{"level": `)
+	time.Sleep(100 * time.Millisecond)
+	write(`{"level": "debug"}`)
+
+	for deadline := time.Now().Add(5 * time.Second); level.Load() != "debug"; {
+		if time.Now().After(deadline) {
+			t.Fatalf("level = %v after the file was fixed, want debug", level.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,11 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/danielpadmore/cloudygo-service/logs"
+)
+
+func newLog(message string, a ...interface{}) logs.LogStruct {
+	return logs.NewLog("CONFIG", fmt.Sprintf(message, a...))
+}
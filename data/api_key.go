@@ -0,0 +1,68 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/google/uuid"
+)
+
+// CreateAPIKey stores a new API key for the user in key.UserID
+func (c *PostgresSQL) CreateAPIKey(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	created := model.APIKey{}
+
+	err := c.db.GetContext(ctx, &created,
+		`INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, now())
+		RETURNING *`,
+		uuid.New().String(), key.UserID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt)
+
+	return created, err
+}
+
+// GetAPIKeys fetches a user's API keys which have not been revoked
+func (c *PostgresSQL) GetAPIKeys(ctx context.Context, userID string) (model.APIKeys, error) {
+	keys := model.APIKeys{}
+
+	err := c.db.SelectContext(ctx, &keys,
+		`SELECT * FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at, id`, userID)
+
+	return keys, err
+}
+
+// RevokeAPIKey revokes one of a user's API keys. ErrNotFound is returned if the user holds no such key.
+func (c *PostgresSQL) RevokeAPIKey(ctx context.Context, userID string, keyID string) error {
+	res, err := c.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		keyID, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey fetches the active API key with the given hash and records that it was used.
+// ErrNotFound is returned if the key is unknown, expired or revoked.
+func (c *PostgresSQL) AuthenticateAPIKey(ctx context.Context, keyHash string) (model.APIKey, error) {
+	key := model.APIKey{}
+
+	err := c.db.GetContext(ctx, &key,
+		`UPDATE api_keys SET last_used_at = now()
+		WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())
+		RETURNING *`, keyHash)
+	if err == sql.ErrNoRows {
+		return key, ErrNotFound
+	}
+
+	return key, err
+}
@@ -0,0 +1,41 @@
+package data
+
+import (
+	"context"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/google/uuid"
+)
+
+// CreateAuditEvent appends an event to the audit log
+func (c *PostgresSQL) CreateAuditEvent(ctx context.Context, event model.AuditEvent) error {
+	_, err := c.db.ExecContext(ctx,
+		`INSERT INTO audit_events (id, user_id, action, resource_type, resource_id, before, after, source_ip, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())`,
+		uuid.New().String(), event.UserID, event.Action, event.ResourceType, event.ResourceID,
+		[]byte(event.Before), []byte(event.After), event.SourceIP, event.RequestID)
+	return err
+}
+
+// GetAuditEvents fetches a page of the events a user caused, returning the cursor of the next page if there is one
+func (c *PostgresSQL) GetAuditEvents(ctx context.Context, userID string, opts model.ListOptions) (model.AuditEvents, string, error) {
+	events := model.AuditEvents{}
+
+	query, args, err := pageQuery("audit_events", "*", model.AuditEvent{}, []string{"user_id = $1"}, []interface{}{userID}, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = c.db.SelectContext(ctx, &events, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if opts.Limit > 0 && len(events) > opts.Limit {
+		events = events[:opts.Limit]
+		next = encodeCursor(opts, events[len(events)-1])
+	}
+
+	return events, next, nil
+}
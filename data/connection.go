@@ -1,6 +1,9 @@
 package data
 
 import (
+	"context"
+	"time"
+
 	"github.com/danielpadmore/cloudygo-service/logs"
 	"github.com/danielpadmore/cloudygo-service/model"
 	"github.com/jmoiron/sqlx"
@@ -9,26 +12,57 @@ import (
 
 // Connection interface controls methods for database access patterns
 type Connection interface {
-	IsConnected() (bool, error)
-	GetResources() (model.Resources, error)
-	CreateUser(string, string) (model.User, error)
-	AuthenticateUser(string, string) (model.User, error)
-	CreateLambda(string, model.Lambda) (model.Lambda, error)
-	GetLambdas(string, *string) (model.Lambdas, error)
-	UpdateLambda(string, string, model.Lambda) (model.Lambda, error)
-	DeleteLambda(string, string) error
-	CreateVirtualMachine(string, model.VirtualMachine) (model.VirtualMachine, error)
-	GetVirtualMachines(string, *string) (model.VirtualMachines, error)
-	UpdateVirtualMachine(string, string, model.VirtualMachine) (model.VirtualMachine, error)
-	DeleteVirtualMachine(string, string) error
-	CreateSQLDatabase(string, model.SQLDatabase) (model.SQLDatabase, error)
-	GetSQLDatabases(string, *string) (model.SQLDatabases, error)
-	UpdateSQLDatabase(string, string, model.SQLDatabase) (model.SQLDatabase, error)
-	DeleteSQLDatabase(string, string) error
-	CreateNoSQLDatabase(string, model.NoSQLDatabase) (model.NoSQLDatabase, error)
-	GetNoSQLDatabases(string, *string) (model.NoSQLDatabases, error)
-	UpdateNoSQLDatabase(string, string, model.NoSQLDatabase) (model.NoSQLDatabase, error)
-	DeleteNoSQLDatabase(string, string) error
+	IsConnected(context.Context) (bool, error)
+	Close() error
+	CreateUser(context.Context, string, string) (model.User, error)
+	AuthenticateUser(context.Context, string, string) (model.User, error)
+	GetUser(context.Context, string) (model.User, error)
+	GetUserByUsername(context.Context, string) (model.User, error)
+	GetUsers(context.Context, model.ListOptions) (model.Users, string, error)
+	SetUserDisabled(context.Context, string, bool) error
+	DeleteUser(context.Context, string) error
+	ResetPassword(context.Context, string, string) error
+	ChangePassword(context.Context, string, string) error
+	RecordLoginFailure(context.Context, string, time.Duration) (int, error)
+	LockLogin(context.Context, string, time.Duration) error
+	GetLoginLockout(context.Context, []string) (time.Duration, error)
+	ClearLoginFailures(context.Context, string) error
+	CreateRefreshToken(context.Context, string, string, []string, time.Duration) error
+	RotateRefreshToken(context.Context, string, string, time.Duration) (model.RefreshToken, error)
+	RevokeRefreshToken(context.Context, string, string) error
+	RevokeToken(context.Context, string, time.Time) error
+	IsTokenRevoked(context.Context, string) (bool, error)
+	CreateAPIKey(context.Context, model.APIKey) (model.APIKey, error)
+	GetAPIKeys(context.Context, string) (model.APIKeys, error)
+	RevokeAPIKey(context.Context, string, string) error
+	AuthenticateAPIKey(context.Context, string) (model.APIKey, error)
+	CreateOrganization(context.Context, string, string) (model.Organization, error)
+	GetOrganizations(context.Context, string) (model.Organizations, error)
+	GetOrganization(context.Context, string, string) (model.Organization, error)
+	DeleteOrganization(context.Context, string) error
+	GetMembers(context.Context, string) (model.Memberships, error)
+	GetMembership(context.Context, string, string) (model.Membership, error)
+	AddMember(context.Context, model.Membership) (model.Membership, error)
+	UpdateMember(context.Context, string, string, string) (model.Membership, error)
+	RemoveMember(context.Context, string, string) error
+	CreateResource(context.Context, string, string, interface{}) (interface{}, error)
+	GetResource(context.Context, string, string, string) (interface{}, error)
+	GetResources(context.Context, string, string, model.ListOptions) ([]interface{}, string, error)
+	UpdateResource(context.Context, string, string, string, interface{}, *int) (interface{}, error)
+	PatchResource(context.Context, string, string, string, interface{}, []string, *int) (interface{}, error)
+	DeleteResource(context.Context, string, string, string, *int) error
+	GetResourcesByStatus(context.Context, string, time.Duration) ([]model.ResourceRef, error)
+	TransitionResource(context.Context, model.ResourceRef, string) error
+	CountResources(context.Context) ([]model.ResourceCount, error)
+	RestoreResource(context.Context, string, string, string, time.Duration) error
+	PurgeResources(context.Context, time.Duration) ([]model.ResourceRef, error)
+	GetQuotaUsage(context.Context, string) (model.QuotaUsages, error)
+	CreateIdempotencyKey(context.Context, string, string, string, time.Duration) error
+	GetIdempotencyKey(context.Context, string, string) (model.IdempotencyRecord, error)
+	CompleteIdempotencyKey(context.Context, model.IdempotencyRecord) error
+	DeleteIdempotencyKey(context.Context, string, string) error
+	CreateAuditEvent(context.Context, model.AuditEvent) error
+	GetAuditEvents(context.Context, string, model.ListOptions) (model.AuditEvents, string, error)
 }
 
 // PostgresSQL contains database connection data
@@ -47,9 +81,14 @@ func New(logger logs.Logger, connection string) (Connection, error) {
 	return &PostgresSQL{logger, db}, nil
 }
 
+// Close closes the connection pool, waiting for queries in progress to finish
+func (c *PostgresSQL) Close() error {
+	return c.db.Close()
+}
+
 // IsConnected checks the connection to the database and returns an error if not connected
-func (c *PostgresSQL) IsConnected() (bool, error) {
-	err := c.db.Ping()
+func (c *PostgresSQL) IsConnected(ctx context.Context) (bool, error) {
+	err := c.db.PingContext(ctx)
 	if err != nil {
 		return false, err
 	}
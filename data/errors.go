@@ -0,0 +1,52 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres error code returned when a write breaks a unique index
+const uniqueViolation = "23505"
+
+// unknownType returns the error for a resource type which has not been registered
+func unknownType(resourceType string) error {
+	return fmt.Errorf("unknown resource type %s", resourceType)
+}
+
+// nameError returns ErrAlreadyExists if err is a write breaking the unique index on the names of a resource type's
+// live resources, otherwise err itself
+func nameError(err error, resourceType string, name interface{}) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && strings.HasSuffix(pqErr.Constraint, "_name") {
+		return fmt.Errorf("%w: another %s is named %v", ErrAlreadyExists, resourceType, name)
+	}
+	return err
+}
+
+// ErrNotFound is returned when a requested row does not exist or the user can not access it or one of their organizations
+var ErrNotFound = errors.New("Not found")
+
+// ErrInvalidListOptions is returned when list options reference unknown fields or a malformed cursor
+var ErrInvalidListOptions = errors.New("Invalid list options")
+
+// ErrVersionMismatch is returned when a row has changed since the version the caller last read
+var ErrVersionMismatch = errors.New("Version mismatch")
+
+// ErrAlreadyExists is returned when creating a row whose key is already in use, or giving a resource a name another
+// live resource of the same type and owner holds
+var ErrAlreadyExists = errors.New("Already exists")
+
+// ErrDeleting is returned when changing a resource which is being deleted
+var ErrDeleting = errors.New("Deleting")
+
+// ErrQuotaExceeded is returned when a create or update would take a user over their quota
+var ErrQuotaExceeded = errors.New("Quota exceeded")
+
+// ErrNotEmpty is returned when deleting an organization which still owns resources
+var ErrNotEmpty = errors.New("Not empty")
+
+// ErrLastOwner is returned when a change would leave an organization without an owner
+var ErrLastOwner = errors.New("Last owner")
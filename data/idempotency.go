@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+)
+
+// CreateIdempotencyKey reserves a key for a user while the request it was sent with is processed.
+// ErrAlreadyExists is returned if the key is already held and has not expired.
+func (c *PostgresSQL) CreateIdempotencyKey(ctx context.Context, userID string, key string, requestHash string, ttl time.Duration) error {
+	_, err := c.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE user_id = $1 AND expires_at <= now()`, userID)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.db.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, status_code, headers, body, created_at, expires_at)
+		VALUES ($1, $2, $3, 0, '', '', now(), now() + make_interval(secs => $4))
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING`,
+		userID, key, requestHash, ttl.Seconds())
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAlreadyExists
+	}
+
+	return nil
+}
+
+// GetIdempotencyKey fetches an unexpired key held by a user
+func (c *PostgresSQL) GetIdempotencyKey(ctx context.Context, userID string, key string) (model.IdempotencyRecord, error) {
+	record := model.IdempotencyRecord{}
+
+	err := c.db.GetContext(ctx, &record,
+		`SELECT * FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2 AND expires_at > now()`,
+		userID, key)
+	if err == sql.ErrNoRows {
+		return record, ErrNotFound
+	}
+	if err != nil {
+		return record, err
+	}
+
+	return record, nil
+}
+
+// CompleteIdempotencyKey stores the response to the request a key was reserved for
+func (c *PostgresSQL) CompleteIdempotencyKey(ctx context.Context, record model.IdempotencyRecord) error {
+	_, err := c.db.NamedExecContext(ctx,
+		`UPDATE idempotency_keys SET (status_code, headers, body) = (:status_code, :headers, :body)
+		WHERE user_id = :user_id AND idempotency_key = :idempotency_key`, record)
+
+	return err
+}
+
+// DeleteIdempotencyKey releases a key so the request can be retried
+func (c *PostgresSQL) DeleteIdempotencyKey(ctx context.Context, userID string, key string) error {
+	_, err := c.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`, userID, key)
+
+	return err
+}
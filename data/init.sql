@@ -1,100 +1,225 @@
 set time zone 'UTC';
 create extension pgcrypto;
 
-CREATE TABLE resources (
-    id VARCHAR (255) PRIMARY KEY,
-    name VARCHAR (255) NOT NULL,
-    type VARCHAR (255) NOT NULL,
-    available BOOLEAN
-);
-
 CREATE TABLE users (
     id VARCHAR (255) PRIMARY KEY, 
     username VARCHAR (255) NOT NULL UNIQUE,
     password TEXT NOT NULL,
+    is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    disabled_at TIMESTAMP,
+    tokens_revoked_at TIMESTAMP,
+    password_reset_required BOOLEAN NOT NULL DEFAULT FALSE,
     created_at TIMESTAMP NOT NULL, 
     updated_at TIMESTAMP NOT NULL, 
     deleted_at TIMESTAMP
 );
 
+CREATE TABLE organizations (
+    id VARCHAR (255) PRIMARY KEY,
+    name VARCHAR (255) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE organization_members (
+    organization_id VARCHAR (255) NOT NULL,
+    user_id VARCHAR (255) NOT NULL,
+    role VARCHAR (32) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX organization_members_user_id ON organization_members (user_id);
+
 CREATE TABLE lambdas (
     id VARCHAR (255) PRIMARY KEY,
     user_id VARCHAR (255),
+    organization_id VARCHAR (255),
     name VARCHAR (255),
     concurrent_limit INT NOT NULL,
+    tags JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR (32) NOT NULL DEFAULT 'pending',
+    version INT NOT NULL DEFAULT 1,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL,
     deleted_at TIMESTAMP
 );
 
+CREATE INDEX lambdas_deleted_at ON lambdas (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX lambdas_tags ON lambdas USING GIN (tags);
+CREATE UNIQUE INDEX lambdas_user_id_name ON lambdas (user_id, name) WHERE organization_id IS NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX lambdas_organization_id_name ON lambdas (organization_id, name) WHERE organization_id IS NOT NULL AND deleted_at IS NULL;
+
 CREATE TABLE virtual_machines (
     id VARCHAR (255) PRIMARY KEY,
     user_id VARCHAR (255),
+    organization_id VARCHAR (255),
     name VARCHAR (255),
     cpus INT NOT NULL,
     quantity INT NOT NULL,
+    tags JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR (32) NOT NULL DEFAULT 'pending',
+    version INT NOT NULL DEFAULT 1,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL,
     deleted_at TIMESTAMP
 );
 
+CREATE INDEX virtual_machines_deleted_at ON virtual_machines (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX virtual_machines_tags ON virtual_machines USING GIN (tags);
+CREATE UNIQUE INDEX virtual_machines_user_id_name ON virtual_machines (user_id, name) WHERE organization_id IS NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX virtual_machines_organization_id_name ON virtual_machines (organization_id, name) WHERE organization_id IS NOT NULL AND deleted_at IS NULL;
+
 CREATE TABLE sql_databases (
     id VARCHAR (255) PRIMARY KEY,
     user_id VARCHAR (255),
+    organization_id VARCHAR (255),
     name VARCHAR (255),
     username VARCHAR (255) NOT NULL,
     password VARCHAR (255) NOT NULL,
     quantity INT NOT NULL,
+    tags JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR (32) NOT NULL DEFAULT 'pending',
+    version INT NOT NULL DEFAULT 1,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL,
     deleted_at TIMESTAMP
 );
 
+CREATE INDEX sql_databases_deleted_at ON sql_databases (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX sql_databases_tags ON sql_databases USING GIN (tags);
+CREATE UNIQUE INDEX sql_databases_user_id_name ON sql_databases (user_id, name) WHERE organization_id IS NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX sql_databases_organization_id_name ON sql_databases (organization_id, name) WHERE organization_id IS NOT NULL AND deleted_at IS NULL;
+
 CREATE TABLE nosql_databases (
     id VARCHAR (255) PRIMARY KEY,
     user_id VARCHAR (255),
+    organization_id VARCHAR (255),
     name VARCHAR (255),
     shards INT NOT NULL,
+    tags JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR (32) NOT NULL DEFAULT 'pending',
+    version INT NOT NULL DEFAULT 1,
     created_at TIMESTAMP NOT NULL,
     updated_at TIMESTAMP NOT NULL,
     deleted_at TIMESTAMP
 );
 
-INSERT INTO resources (id, name, type, available) VALUES ('resource-001', 'Serverless Lambda Function', 'lambda', TRUE);
-INSERT INTO resources (id, name, type, available) VALUES ('resource-002', 'Virtual Machine', 'virtual_machine', TRUE);
-INSERT INTO resources (id, name, type, available) VALUES ('resource-003', 'SQL Database', 'sql_database', TRUE);
-INSERT INTO resources (id, name, type, available) VALUES ('resource-004', 'No-SQL Database', 'nosql_database', TRUE);
+CREATE INDEX nosql_databases_deleted_at ON nosql_databases (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX nosql_databases_tags ON nosql_databases USING GIN (tags);
+CREATE UNIQUE INDEX nosql_databases_user_id_name ON nosql_databases (user_id, name) WHERE organization_id IS NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX nosql_databases_organization_id_name ON nosql_databases (organization_id, name) WHERE organization_id IS NOT NULL AND deleted_at IS NULL;
+
+CREATE TABLE idempotency_keys (
+    user_id VARCHAR (255) NOT NULL,
+    idempotency_key VARCHAR (255) NOT NULL,
+    request_hash VARCHAR (64) NOT NULL,
+    status_code INT NOT NULL,
+    headers TEXT NOT NULL,
+    body BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+CREATE TABLE refresh_tokens (
+    id VARCHAR (255) PRIMARY KEY,
+    user_id VARCHAR (255) NOT NULL,
+    token_hash VARCHAR (64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE TABLE revoked_tokens (
+    jti VARCHAR (255) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE login_failures (
+    login_key VARCHAR (255) PRIMARY KEY,
+    failures INT NOT NULL,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+CREATE TABLE api_keys (
+    id VARCHAR (255) PRIMARY KEY,
+    user_id VARCHAR (255) NOT NULL,
+    name VARCHAR (255) NOT NULL,
+    prefix VARCHAR (32) NOT NULL,
+    key_hash VARCHAR (64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE TABLE quotas (
+    user_id VARCHAR (255) NOT NULL,
+    resource_type VARCHAR (255) NOT NULL,
+    max_count INT,
+    max_capacity INT,
+    PRIMARY KEY (user_id, resource_type)
+);
 
 INSERT INTO users (id, username, password, created_at, updated_at) VALUES ('demo-user-001', 'password', CURRENT_DATE, CURRENT_DATE);
 
-INSERT INTO lambdas (id, user_id, name, concurrent_limit, created_at, updated_at) VALUES ('preset-lambda-001', 'demo-user-001', 'My preset lambda 1', 1, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO lambdas (id, user_id, name, concurrent_limit, created_at, updated_at) VALUES ('preset-lambda-002', 'demo-user-001', 'My preset lambda 2', 10, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO lambdas (id, user_id, name, concurrent_limit, created_at, updated_at) VALUES ('preset-lambda-003', 'demo-user-001', 'My preset lambda 3', 25, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO lambdas (id, user_id, name, concurrent_limit, created_at, updated_at) VALUES ('preset-lambda-004', 'demo-user-001', 'My preset lambda 4', 43, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO lambdas (id, user_id, name, concurrent_limit, created_at, updated_at) VALUES ('preset-lambda-005', 'demo-user-001', 'My preset lambda 5', 50, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO lambdas (id, user_id, name, concurrent_limit, created_at, updated_at) VALUES ('preset-lambda-006', 'demo-user-001', 'My preset lambda 6', 76, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO lambdas (id, user_id, name, concurrent_limit, created_at, updated_at) VALUES ('preset-lambda-007', 'demo-user-001', 'My preset lambda 7', 100, CURRENT_DATE, CURRENT_DATE);
-
-INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, created_at, updated_at) VALUES ('preset-vm-001', 'demo-user-001', 'My preset virtual machine 1', 1, 1, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, created_at, updated_at) VALUES ('preset-vm-002', 'demo-user-001', 'My preset virtual machine 2', 4, 2, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, created_at, updated_at) VALUES ('preset-vm-003', 'demo-user-001', 'My preset virtual machine 3', 2, 3, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, created_at, updated_at) VALUES ('preset-vm-004', 'demo-user-001', 'My preset virtual machine 4', 1, 4, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, created_at, updated_at) VALUES ('preset-vm-005', 'demo-user-001', 'My preset virtual machine 5', 2, 1, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, created_at, updated_at) VALUES ('preset-vm-006', 'demo-user-001', 'My preset virtual machine 6', 8, 2, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, created_at, updated_at) VALUES ('preset-vm-007', 'demo-user-001', 'My preset virtual machine 7', 2, 3, CURRENT_DATE, CURRENT_DATE);
-
-INSERT INTO sql_databases (id, user_id, name, username, password, quantity, created_at, updated_at) VALUES ('preset-sql-db-001', 'demo-user-001', 'My preset sql database 1', 'db-admin', 'dbpassword1', 1, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO sql_databases (id, user_id, name, username, password, quantity, created_at, updated_at) VALUES ('preset-sql-db-002', 'demo-user-001', 'My preset sql database 2', 'db-admin', 'dbpassword1', 3, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO sql_databases (id, user_id, name, username, password, quantity, created_at, updated_at) VALUES ('preset-sql-db-003', 'demo-user-001', 'My preset sql database 3', 'db-admin', 'dbpassword1', 2, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO sql_databases (id, user_id, name, username, password, quantity, created_at, updated_at) VALUES ('preset-sql-db-004', 'demo-user-001', 'My preset sql database 4', 'db-admin', 'dbpassword1', 2, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO sql_databases (id, user_id, name, username, password, quantity, created_at, updated_at) VALUES ('preset-sql-db-005', 'demo-user-001', 'My preset sql database 5', 'db-admin', 'dbpassword1', 2, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO sql_databases (id, user_id, name, username, password, quantity, created_at, updated_at) VALUES ('preset-sql-db-006', 'demo-user-001', 'My preset sql database 6', 'db-admin', 'dbpassword1', 3, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO sql_databases (id, user_id, name, username, password, quantity, created_at, updated_at) VALUES ('preset-sql-db-007', 'demo-user-001', 'My preset sql database 7', 'db-admin', 'dbpassword1', 2, CURRENT_DATE, CURRENT_DATE);
-
-INSERT INTO nosql_databases (id, user_id, name, shards, created_at, updated_at) VALUES ('preset-nosql-db-001', 'demo-user-001', 'My preset No SQL database 1', 10, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO nosql_databases (id, user_id, name, shards, created_at, updated_at) VALUES ('preset-nosql-db-002', 'demo-user-001', 'My preset No SQL database 2', 20, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO nosql_databases (id, user_id, name, shards, created_at, updated_at) VALUES ('preset-nosql-db-003', 'demo-user-001', 'My preset No SQL database 3', 30, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO nosql_databases (id, user_id, name, shards, created_at, updated_at) VALUES ('preset-nosql-db-004', 'demo-user-001', 'My preset No SQL database 4', 40, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO nosql_databases (id, user_id, name, shards, created_at, updated_at) VALUES ('preset-nosql-db-005', 'demo-user-001', 'My preset No SQL database 5', 10, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO nosql_databases (id, user_id, name, shards, created_at, updated_at) VALUES ('preset-nosql-db-006', 'demo-user-001', 'My preset No SQL database 6', 20, CURRENT_DATE, CURRENT_DATE);
-INSERT INTO nosql_databases (id, user_id, name, shards, created_at, updated_at) VALUES ('preset-nosql-db-007', 'demo-user-001', 'My preset No SQL database 7', 30, CURRENT_DATE, CURRENT_DATE);
+INSERT INTO lambdas (id, user_id, name, concurrent_limit, status, created_at, updated_at) VALUES ('preset-lambda-001', 'demo-user-001', 'My preset lambda 1', 1, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO lambdas (id, user_id, name, concurrent_limit, status, created_at, updated_at) VALUES ('preset-lambda-002', 'demo-user-001', 'My preset lambda 2', 10, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO lambdas (id, user_id, name, concurrent_limit, status, created_at, updated_at) VALUES ('preset-lambda-003', 'demo-user-001', 'My preset lambda 3', 25, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO lambdas (id, user_id, name, concurrent_limit, status, created_at, updated_at) VALUES ('preset-lambda-004', 'demo-user-001', 'My preset lambda 4', 43, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO lambdas (id, user_id, name, concurrent_limit, status, created_at, updated_at) VALUES ('preset-lambda-005', 'demo-user-001', 'My preset lambda 5', 50, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO lambdas (id, user_id, name, concurrent_limit, status, created_at, updated_at) VALUES ('preset-lambda-006', 'demo-user-001', 'My preset lambda 6', 76, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO lambdas (id, user_id, name, concurrent_limit, status, created_at, updated_at) VALUES ('preset-lambda-007', 'demo-user-001', 'My preset lambda 7', 100, 'running', CURRENT_DATE, CURRENT_DATE);
+
+INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, status, created_at, updated_at) VALUES ('preset-vm-001', 'demo-user-001', 'My preset virtual machine 1', 1, 1, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, status, created_at, updated_at) VALUES ('preset-vm-002', 'demo-user-001', 'My preset virtual machine 2', 4, 2, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, status, created_at, updated_at) VALUES ('preset-vm-003', 'demo-user-001', 'My preset virtual machine 3', 2, 3, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, status, created_at, updated_at) VALUES ('preset-vm-004', 'demo-user-001', 'My preset virtual machine 4', 1, 4, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, status, created_at, updated_at) VALUES ('preset-vm-005', 'demo-user-001', 'My preset virtual machine 5', 2, 1, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, status, created_at, updated_at) VALUES ('preset-vm-006', 'demo-user-001', 'My preset virtual machine 6', 8, 2, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO virtual_machines (id, user_id, name, cpus, quantity, status, created_at, updated_at) VALUES ('preset-vm-007', 'demo-user-001', 'My preset virtual machine 7', 2, 3, 'running', CURRENT_DATE, CURRENT_DATE);
+
+INSERT INTO sql_databases (id, user_id, name, username, password, quantity, status, created_at, updated_at) VALUES ('preset-sql-db-001', 'demo-user-001', 'My preset sql database 1', 'db-admin', 'dbpassword1', 1, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO sql_databases (id, user_id, name, username, password, quantity, status, created_at, updated_at) VALUES ('preset-sql-db-002', 'demo-user-001', 'My preset sql database 2', 'db-admin', 'dbpassword1', 3, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO sql_databases (id, user_id, name, username, password, quantity, status, created_at, updated_at) VALUES ('preset-sql-db-003', 'demo-user-001', 'My preset sql database 3', 'db-admin', 'dbpassword1', 2, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO sql_databases (id, user_id, name, username, password, quantity, status, created_at, updated_at) VALUES ('preset-sql-db-004', 'demo-user-001', 'My preset sql database 4', 'db-admin', 'dbpassword1', 2, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO sql_databases (id, user_id, name, username, password, quantity, status, created_at, updated_at) VALUES ('preset-sql-db-005', 'demo-user-001', 'My preset sql database 5', 'db-admin', 'dbpassword1', 2, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO sql_databases (id, user_id, name, username, password, quantity, status, created_at, updated_at) VALUES ('preset-sql-db-006', 'demo-user-001', 'My preset sql database 6', 'db-admin', 'dbpassword1', 3, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO sql_databases (id, user_id, name, username, password, quantity, status, created_at, updated_at) VALUES ('preset-sql-db-007', 'demo-user-001', 'My preset sql database 7', 'db-admin', 'dbpassword1', 2, 'running', CURRENT_DATE, CURRENT_DATE);
+
+INSERT INTO nosql_databases (id, user_id, name, shards, status, created_at, updated_at) VALUES ('preset-nosql-db-001', 'demo-user-001', 'My preset No SQL database 1', 10, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO nosql_databases (id, user_id, name, shards, status, created_at, updated_at) VALUES ('preset-nosql-db-002', 'demo-user-001', 'My preset No SQL database 2', 20, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO nosql_databases (id, user_id, name, shards, status, created_at, updated_at) VALUES ('preset-nosql-db-003', 'demo-user-001', 'My preset No SQL database 3', 30, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO nosql_databases (id, user_id, name, shards, status, created_at, updated_at) VALUES ('preset-nosql-db-004', 'demo-user-001', 'My preset No SQL database 4', 40, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO nosql_databases (id, user_id, name, shards, status, created_at, updated_at) VALUES ('preset-nosql-db-005', 'demo-user-001', 'My preset No SQL database 5', 10, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO nosql_databases (id, user_id, name, shards, status, created_at, updated_at) VALUES ('preset-nosql-db-006', 'demo-user-001', 'My preset No SQL database 6', 20, 'running', CURRENT_DATE, CURRENT_DATE);
+INSERT INTO nosql_databases (id, user_id, name, shards, status, created_at, updated_at) VALUES ('preset-nosql-db-007', 'demo-user-001', 'My preset No SQL database 7', 30, 'running', CURRENT_DATE, CURRENT_DATE);
+
+CREATE TABLE audit_events (
+    id VARCHAR (255) PRIMARY KEY,
+    user_id VARCHAR (255) NOT NULL,
+    action VARCHAR (255) NOT NULL,
+    resource_type VARCHAR (255) NOT NULL,
+    resource_id VARCHAR (255) NOT NULL,
+    before JSONB NOT NULL DEFAULT 'null',
+    after JSONB NOT NULL DEFAULT 'null',
+    source_ip VARCHAR (255) NOT NULL,
+    request_id VARCHAR (255) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX audit_events_user_id_created_at ON audit_events (user_id, created_at, id);
+
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE PROCEDURE audit_events_append_only();
@@ -0,0 +1,317 @@
+package data
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/lib/pq"
+)
+
+// cursor marks the last row of a page, in terms of the sort field and row id
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func sortColumn(opts model.ListOptions) string {
+	if opts.Sort == "" {
+		return "created_at"
+	}
+	return opts.Sort
+}
+
+// columnValue returns the value of the struct field tagged with the given db column.
+// Nullable pointer fields are dereferenced, giving nil for NULL.
+func columnValue(row interface{}, column string) (interface{}, bool) {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("db"), ",")[0] != column {
+			continue
+		}
+		field := v.Field(i)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				return nil, true
+			}
+			field = field.Elem()
+		}
+		return field.Interface(), true
+	}
+	return nil, false
+}
+
+func formatCursorValue(v interface{}) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+// encodeCursor returns the opaque cursor pointing after row
+func encodeCursor(opts model.ListOptions, row interface{}) string {
+	column := sortColumn(opts)
+	value, _ := columnValue(row, column)
+	id, _ := columnValue(row, "id")
+
+	d, _ := json.Marshal(cursor{Sort: column, Value: formatCursorValue(value), ID: fmt.Sprint(id)})
+	return base64.RawURLEncoding.EncodeToString(d)
+}
+
+// decodeCursor parses the cursor in opts, returning nil when listing from the start
+func decodeCursor(opts model.ListOptions) (*cursor, error) {
+	if opts.Cursor == "" {
+		return nil, nil
+	}
+
+	d, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+
+	c := &cursor{}
+	if err := json.Unmarshal(d, c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+
+	if c.Sort != sortColumn(opts) {
+		return nil, fmt.Errorf("%w: cursor does not match sort %s", ErrInvalidListOptions, sortColumn(opts))
+	}
+
+	return c, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// listQuery builds a SELECT of the live rows in table the user can access, applying the filters, sort and cursor in opts.
+// Rows deleted within opts.DeletedWithin are selected instead of live rows when it is set.
+// The limit is one more than requested so callers can tell whether another page exists.
+func listQuery(table string, row interface{}, userID string, opts model.ListOptions) (string, []interface{}, error) {
+	if opts.DeletedWithin > 0 {
+		return pageQuery(table, "*", row, []string{accessibleBy("$1"), "deleted_at > now() - make_interval(secs => $2)"},
+			[]interface{}{userID, opts.DeletedWithin.Seconds()}, opts)
+	}
+	return pageQuery(table, "*", row, []string{accessibleBy("$1"), "deleted_at IS NULL"}, []interface{}{userID}, opts)
+}
+
+// pageQuery builds a SELECT of columns from the rows in table matching the conditions in where, which may refer
+// to args, then applies the filters, sort and cursor in opts like listQuery
+func pageQuery(table string, columns string, row interface{}, where []string, args []interface{}, opts model.ListOptions) (string, []interface{}, error) {
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	for _, f := range opts.Filters {
+		if _, ok := columnValue(row, f.Field); !ok {
+			return "", nil, fmt.Errorf("%w: unknown filter field %s", ErrInvalidListOptions, f.Field)
+		}
+
+		column := pq.QuoteIdentifier(f.Field)
+		switch f.Operator {
+		case model.FilterEquals:
+			where = append(where, fmt.Sprintf("%s = %s", column, arg(f.Value)))
+		case model.FilterPrefix:
+			where = append(where, fmt.Sprintf("%s LIKE %s", column, arg(escapeLike(fmt.Sprint(f.Value))+"%")))
+		case model.FilterGreaterOrEqual:
+			where = append(where, fmt.Sprintf("%s >= %s", column, arg(f.Value)))
+		case model.FilterLessOrEqual:
+			where = append(where, fmt.Sprintf("%s <= %s", column, arg(f.Value)))
+		case model.FilterContains:
+			where = append(where, fmt.Sprintf("%s @> %s", column, arg(f.Value)))
+		default:
+			return "", nil, fmt.Errorf("%w: unknown filter operator %s", ErrInvalidListOptions, f.Operator)
+		}
+	}
+
+	sortBy := sortColumn(opts)
+	if _, ok := columnValue(row, sortBy); !ok {
+		return "", nil, fmt.Errorf("%w: unknown sort field %s", ErrInvalidListOptions, sortBy)
+	}
+	sortBy = pq.QuoteIdentifier(sortBy)
+
+	direction, comparison := "ASC", ">"
+	if opts.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	c, err := decodeCursor(opts)
+	if err != nil {
+		return "", nil, err
+	}
+	if c != nil {
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", sortBy, comparison, arg(c.Value), arg(c.ID)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s %s, id %s",
+		columns, pq.QuoteIdentifier(table), strings.Join(where, " AND "), sortBy, direction, direction)
+	if opts.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", opts.Limit+1)
+	}
+
+	return query, args, nil
+}
+
+// compareValues orders two column values, returning -1, 0 or 1.
+// Timestamps held as strings are compared as times when the other value is a time.
+func compareValues(a interface{}, b interface{}) int {
+	if _, ok := b.(time.Time); ok {
+		if as, ok := a.(string); ok {
+			at, _ := time.Parse(time.RFC3339Nano, as)
+			a = at
+		}
+	}
+
+	if at, ok := a.(time.Time); ok {
+		bt, _ := b.(time.Time)
+		switch {
+		case at.Before(bt):
+			return -1
+		case at.After(bt):
+			return 1
+		}
+		return 0
+	}
+
+	if af, ok := toFloat(a); ok {
+		bf, _ := toFloat(b)
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// parseCursorValue converts a cursor value back into the type of sample
+func parseCursorValue(sample interface{}, s string) interface{} {
+	if _, ok := sample.(time.Time); ok {
+		t, _ := time.Parse(time.RFC3339Nano, s)
+		return t
+	}
+	if _, ok := toFloat(sample); ok {
+		var f float64
+		fmt.Sscan(s, &f)
+		return f
+	}
+	return s
+}
+
+func matchesFilter(row interface{}, f model.Filter) (bool, error) {
+	value, ok := columnValue(row, f.Field)
+	if !ok {
+		return false, fmt.Errorf("%w: unknown filter field %s", ErrInvalidListOptions, f.Field)
+	}
+
+	switch f.Operator {
+	case model.FilterEquals:
+		return compareValues(value, f.Value) == 0, nil
+	case model.FilterPrefix:
+		return strings.HasPrefix(fmt.Sprint(value), fmt.Sprint(f.Value)), nil
+	case model.FilterGreaterOrEqual:
+		return compareValues(value, f.Value) >= 0, nil
+	case model.FilterLessOrEqual:
+		return compareValues(value, f.Value) <= 0, nil
+	case model.FilterContains:
+		tags, _ := value.(model.Tags)
+		want, _ := f.Value.(model.Tags)
+		return tags.Contains(want), nil
+	}
+
+	return false, fmt.Errorf("%w: unknown filter operator %s", ErrInvalidListOptions, f.Operator)
+}
+
+// pageRows applies the filters, sort, cursor and limit in opts to rows held in memory,
+// mirroring listQuery. It returns the page and the cursor for the following page.
+func pageRows(rows []interface{}, opts model.ListOptions) ([]interface{}, string, error) {
+	sortBy := sortColumn(opts)
+	if len(rows) > 0 {
+		if _, ok := columnValue(rows[0], sortBy); !ok {
+			return nil, "", fmt.Errorf("%w: unknown sort field %s", ErrInvalidListOptions, sortBy)
+		}
+	}
+
+	c, err := decodeCursor(opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// compare orders rows by the sort column then id, honouring the sort direction
+	compare := func(av, aID, bv, bID interface{}) int {
+		result := compareValues(av, bv)
+		if result == 0 {
+			result = compareValues(aID, bID)
+		}
+		if opts.Descending {
+			return -result
+		}
+		return result
+	}
+
+	page := []interface{}{}
+	for _, row := range rows {
+		matches := true
+		for _, f := range opts.Filters {
+			ok, err := matchesFilter(row, f)
+			if err != nil {
+				return nil, "", err
+			}
+			matches = matches && ok
+		}
+		if !matches {
+			continue
+		}
+
+		if c != nil {
+			value, _ := columnValue(row, sortBy)
+			id, _ := columnValue(row, "id")
+			if compare(value, id, parseCursorValue(value, c.Value), c.ID) <= 0 {
+				continue
+			}
+		}
+
+		page = append(page, row)
+	}
+
+	sort.SliceStable(page, func(i, j int) bool {
+		av, _ := columnValue(page[i], sortBy)
+		aID, _ := columnValue(page[i], "id")
+		bv, _ := columnValue(page[j], sortBy)
+		bID, _ := columnValue(page[j], "id")
+		return compare(av, aID, bv, bID) < 0
+	})
+
+	next := ""
+	if opts.Limit > 0 && len(page) > opts.Limit {
+		page = page[:opts.Limit]
+		next = encodeCursor(opts, page[len(page)-1])
+	}
+
+	return page, next, nil
+}
@@ -0,0 +1,52 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RecordLoginFailure counts a failed sign in against key, returning how many failures it has had. Failures more than
+// window after the last failure or lockout of the key are forgotten.
+func (c *PostgresSQL) RecordLoginFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	_, err := c.db.ExecContext(ctx,
+		`DELETE FROM login_failures WHERE GREATEST(last_failed_at, locked_until) <= now() - make_interval(secs => $1)`,
+		window.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	failures := 0
+	err = c.db.GetContext(ctx, &failures,
+		`INSERT INTO login_failures (login_key, failures, last_failed_at) VALUES ($1, 1, now())
+		ON CONFLICT (login_key) DO UPDATE SET failures = login_failures.failures + 1, last_failed_at = now()
+		RETURNING failures`, key)
+
+	return failures, err
+}
+
+// LockLogin locks out sign ins for key until lockout has passed
+func (c *PostgresSQL) LockLogin(ctx context.Context, key string, lockout time.Duration) error {
+	_, err := c.db.ExecContext(ctx,
+		`UPDATE login_failures SET locked_until = now() + make_interval(secs => $2) WHERE login_key = $1`,
+		key, lockout.Seconds())
+	return err
+}
+
+// GetLoginLockout returns how much longer sign ins are locked out for, across all of keys, or 0 if none are locked out
+func (c *PostgresSQL) GetLoginLockout(ctx context.Context, keys []string) (time.Duration, error) {
+	seconds := 0.0
+
+	err := c.db.GetContext(ctx, &seconds,
+		`SELECT COALESCE(MAX(EXTRACT(EPOCH FROM locked_until - now())), 0) FROM login_failures
+		WHERE login_key = ANY($1) AND locked_until > now()`, pq.StringArray(keys))
+
+	return time.Duration(seconds * float64(time.Second)), err
+}
+
+// ClearLoginFailures forgets the failed sign ins counted against key
+func (c *PostgresSQL) ClearLoginFailures(ctx context.Context, key string) error {
+	_, err := c.db.ExecContext(ctx, `DELETE FROM login_failures WHERE login_key = $1`, key)
+	return err
+}
@@ -1,11 +1,12 @@
 package data
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/danielpadmore/cloudygo-service/logs"
 )
 
-func newLog(message string, a ...interface{}) logs.LogStruct {
-	return logs.NewLog("DATABASE", fmt.Sprintf(message, a...))
+func newLog(ctx context.Context, message string, a ...interface{}) logs.LogStruct {
+	return logs.NewLog("DATABASE", fmt.Sprintf(message, a...)).WithContext(ctx)
 }
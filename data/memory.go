@@ -0,0 +1,112 @@
+package data
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+// Memory implements Connection with an in-process store, for tests and offline demos
+type Memory struct {
+	logger          logs.Logger
+	mu              sync.RWMutex
+	users           map[string]model.User
+	resources       map[string]map[string]interface{}
+	idempotencyKeys map[string]model.IdempotencyRecord
+	quotas          map[string]model.Quota
+	refreshTokens   map[string]model.RefreshToken
+	revokedTokens   map[string]time.Time
+	apiKeys         map[string]model.APIKey
+	organizations   map[string]model.Organization
+	members         map[string]model.Membership
+	loginFailures   map[string]loginFailure
+	auditEvents     []model.AuditEvent
+}
+
+// NewMemory creates a new, empty in-memory store
+func NewMemory(logger logs.Logger) Connection {
+	return &Memory{
+		logger:          logger,
+		users:           map[string]model.User{},
+		resources:       map[string]map[string]interface{}{},
+		idempotencyKeys: map[string]model.IdempotencyRecord{},
+		quotas:          map[string]model.Quota{},
+		refreshTokens:   map[string]model.RefreshToken{},
+		revokedTokens:   map[string]time.Time{},
+		apiKeys:         map[string]model.APIKey{},
+		organizations:   map[string]model.Organization{},
+		members:         map[string]model.Membership{},
+		loginFailures:   map[string]loginFailure{},
+	}
+}
+
+// IsConnected always succeeds as there is no remote store to reach
+func (m *Memory) IsConnected(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+// Close does nothing as there is no remote store to disconnect from
+func (m *Memory) Close() error {
+	return nil
+}
+
+// timestampLayout has fixed width fractional seconds so stored timestamps sort as strings
+const timestampLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// now returns the current time formatted as stored in timestamp columns
+func now() string {
+	return time.Now().UTC().Format(timestampLayout)
+}
+
+// timestamp returns the current time at the microsecond precision of timestamp columns
+func timestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// rows returns every row of a resource type keyed by id
+func (m *Memory) rows(resourceType string) map[string]interface{} {
+	rows := map[string]interface{}{}
+	for id, r := range m.resources[resourceType] {
+		rows[id] = r
+	}
+	return rows
+}
+
+// row returns a single row of a resource type
+func (m *Memory) row(resourceType string, id string) (interface{}, bool) {
+	row, ok := m.resources[resourceType][id]
+	return row, ok
+}
+
+// put stores a row of a resource type
+func (m *Memory) put(resourceType string, row interface{}) {
+	if m.resources[resourceType] == nil {
+		m.resources[resourceType] = map[string]interface{}{}
+	}
+	m.resources[resourceType][registry.ID(row)] = row
+}
+
+// remove deletes a row of a resource type
+func (m *Memory) remove(resourceType string, id string) {
+	delete(m.resources[resourceType], id)
+}
+
+// withColumn returns a copy of row with the field tagged with the db column set to value
+func withColumn(row interface{}, column string, value interface{}) interface{} {
+	v := reflect.New(reflect.TypeOf(row)).Elem()
+	v.Set(reflect.ValueOf(row))
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("db") == column {
+			v.Field(i).Set(reflect.ValueOf(value))
+		}
+	}
+
+	return v.Interface()
+}
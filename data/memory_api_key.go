@@ -0,0 +1,90 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/google/uuid"
+)
+
+// CreateAPIKey stores a new API key for the user in key.UserID
+func (m *Memory) CreateAPIKey(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key.ID = uuid.New().String()
+	key.CreatedAt = timestamp()
+	if key.ExpiresAt.Valid {
+		expires, err := time.Parse(time.RFC3339Nano, key.ExpiresAt.String)
+		if err != nil {
+			return model.APIKey{}, err
+		}
+		key.ExpiresAt.String = expires.UTC().Format(timestampLayout)
+	}
+	m.apiKeys[key.ID] = key
+
+	return key, nil
+}
+
+// GetAPIKeys fetches a user's API keys which have not been revoked
+func (m *Memory) GetAPIKeys(ctx context.Context, userID string) (model.APIKeys, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := model.APIKeys{}
+	for _, key := range m.apiKeys {
+		if key.UserID == userID && !key.RevokedAt.Valid {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes one of a user's API keys. ErrNotFound is returned if the user holds no such key.
+func (m *Memory) RevokeAPIKey(ctx context.Context, userID string, keyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.apiKeys[keyID]
+	if !ok || key.UserID != userID || key.RevokedAt.Valid {
+		return ErrNotFound
+	}
+
+	key.RevokedAt = sql.NullString{String: now(), Valid: true}
+	m.apiKeys[keyID] = key
+
+	return nil
+}
+
+// AuthenticateAPIKey fetches the active API key with the given hash and records that it was used.
+// ErrNotFound is returned if the key is unknown, expired or revoked.
+func (m *Memory) AuthenticateAPIKey(ctx context.Context, keyHash string) (model.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, key := range m.apiKeys {
+		if key.KeyHash != keyHash {
+			continue
+		}
+		if key.RevokedAt.Valid || (key.ExpiresAt.Valid && key.ExpiresAt.String <= now()) {
+			break
+		}
+
+		key.LastUsedAt = sql.NullString{String: now(), Valid: true}
+		m.apiKeys[id] = key
+		return key, nil
+	}
+
+	return model.APIKey{}, ErrNotFound
+}
@@ -0,0 +1,45 @@
+package data
+
+import (
+	"context"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/google/uuid"
+)
+
+// CreateAuditEvent appends an event to the audit log
+func (m *Memory) CreateAuditEvent(ctx context.Context, event model.AuditEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	event.ID = uuid.New().String()
+	event.CreatedAt = timestamp()
+	m.auditEvents = append(m.auditEvents, event)
+
+	return nil
+}
+
+// GetAuditEvents fetches a page of the events a user caused, returning the cursor of the next page if there is one
+func (m *Memory) GetAuditEvents(ctx context.Context, userID string, opts model.ListOptions) (model.AuditEvents, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := []interface{}{}
+	for _, event := range m.auditEvents {
+		if event.UserID == userID {
+			rows = append(rows, event)
+		}
+	}
+
+	page, next, err := pageRows(rows, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	events := model.AuditEvents{}
+	for _, r := range page {
+		events = append(events, r.(model.AuditEvent))
+	}
+
+	return events, next, nil
+}
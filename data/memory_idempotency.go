@@ -0,0 +1,76 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+)
+
+func idempotencyMapKey(userID string, key string) string {
+	return userID + "\x00" + key
+}
+
+// CreateIdempotencyKey reserves a key for a user while the request it was sent with is processed.
+// ErrAlreadyExists is returned if the key is already held and has not expired.
+func (m *Memory) CreateIdempotencyKey(ctx context.Context, userID string, key string, requestHash string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mapKey := idempotencyMapKey(userID, key)
+	if existing, ok := m.idempotencyKeys[mapKey]; ok && existing.ExpiresAt > now() {
+		return ErrAlreadyExists
+	}
+
+	m.idempotencyKeys[mapKey] = model.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   timestamp(),
+		ExpiresAt:   time.Now().Add(ttl).UTC().Format(timestampLayout),
+	}
+
+	return nil
+}
+
+// GetIdempotencyKey fetches an unexpired key held by a user
+func (m *Memory) GetIdempotencyKey(ctx context.Context, userID string, key string) (model.IdempotencyRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	record, ok := m.idempotencyKeys[idempotencyMapKey(userID, key)]
+	if !ok || record.ExpiresAt <= now() {
+		return model.IdempotencyRecord{}, ErrNotFound
+	}
+
+	return record, nil
+}
+
+// CompleteIdempotencyKey stores the response to the request a key was reserved for
+func (m *Memory) CompleteIdempotencyKey(ctx context.Context, record model.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mapKey := idempotencyMapKey(record.UserID, record.Key)
+	stored, ok := m.idempotencyKeys[mapKey]
+	if !ok {
+		return nil
+	}
+
+	stored.StatusCode = record.StatusCode
+	stored.Headers = record.Headers
+	stored.Body = record.Body
+	m.idempotencyKeys[mapKey] = stored
+
+	return nil
+}
+
+// DeleteIdempotencyKey releases a key so the request can be retried
+func (m *Memory) DeleteIdempotencyKey(ctx context.Context, userID string, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.idempotencyKeys, idempotencyMapKey(userID, key))
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package data
+
+import (
+	"context"
+	"time"
+)
+
+// loginFailure counts the failed sign ins against a key
+type loginFailure struct {
+	failures     int
+	lastFailedAt time.Time
+	lockedUntil  time.Time
+}
+
+// RecordLoginFailure counts a failed sign in against key, returning how many failures it has had. Failures more than
+// window after the last failure or lockout of the key are forgotten.
+func (m *Memory) RecordLoginFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, f := range m.loginFailures {
+		last := f.lastFailedAt
+		if f.lockedUntil.After(last) {
+			last = f.lockedUntil
+		}
+		if !time.Now().Before(last.Add(window)) {
+			delete(m.loginFailures, k)
+		}
+	}
+
+	f := m.loginFailures[key]
+	f.failures++
+	f.lastFailedAt = time.Now()
+	m.loginFailures[key] = f
+
+	return f.failures, nil
+}
+
+// LockLogin locks out sign ins for key until lockout has passed
+func (m *Memory) LockLogin(ctx context.Context, key string, lockout time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if f, ok := m.loginFailures[key]; ok {
+		f.lockedUntil = time.Now().Add(lockout)
+		m.loginFailures[key] = f
+	}
+
+	return nil
+}
+
+// GetLoginLockout returns how much longer sign ins are locked out for, across all of keys, or 0 if none are locked out
+func (m *Memory) GetLoginLockout(ctx context.Context, keys []string) (time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var remaining time.Duration
+	for _, key := range keys {
+		if left := time.Until(m.loginFailures[key].lockedUntil); left > remaining {
+			remaining = left
+		}
+	}
+
+	return remaining, nil
+}
+
+// ClearLoginFailures forgets the failed sign ins counted against key
+func (m *Memory) ClearLoginFailures(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.loginFailures, key)
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+	"github.com/google/uuid"
+)
+
+// memberKey identifies a user's membership of an organization
+func memberKey(orgID string, userID string) string {
+	return orgID + "/" + userID
+}
+
+// canAccess reports whether a user can access a resource created by creatorID which belongs to orgID, if set,
+// mirroring accessibleBy. Callers must hold m.mu.
+func (m *Memory) canAccess(userID string, creatorID string, orgID *string) bool {
+	if orgID == nil {
+		return creatorID == userID
+	}
+	_, ok := m.members[memberKey(*orgID, userID)]
+	return ok
+}
+
+// canAccessRow reports whether a user can access a live resource row, as canAccess does. Callers must hold m.mu.
+func (m *Memory) canAccessRow(userID string, row interface{}) bool {
+	creator, _ := columnValue(row, "user_id")
+	orgID, _ := columnValue(row, "organization_id")
+	deletedAt, _ := columnValue(row, "deleted_at")
+
+	var org *string
+	if orgID != nil {
+		id := orgID.(string)
+		org = &id
+	}
+
+	return m.canAccess(userID, creator.(string), org) && !deletedAt.(sql.NullString).Valid
+}
+
+// liveOrganization returns an organization which has not been deleted. Callers must hold m.mu.
+func (m *Memory) liveOrganization(orgID string) (model.Organization, bool) {
+	org, ok := m.organizations[orgID]
+	return org, ok && !org.DeletedAt.Valid
+}
+
+// countOwners counts the owners of an organization. Callers must hold m.mu.
+func (m *Memory) countOwners(orgID string) int {
+	owners := 0
+	for _, member := range m.members {
+		if member.OrganizationID == orgID && member.Role == model.RoleOwner {
+			owners++
+		}
+	}
+	return owners
+}
+
+// withUsername returns a membership with the member's username filled in. Callers must hold m.mu.
+func (m *Memory) withUsername(member model.Membership) model.Membership {
+	member.Username = m.users[member.UserID].Username
+	return member
+}
+
+// CreateOrganization creates a new organization with the user as its owner
+func (m *Memory) CreateOrganization(ctx context.Context, userID string, name string) (model.Organization, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	org := model.Organization{
+		ID:        uuid.New().String(),
+		Name:      name,
+		CreatedAt: timestamp(),
+		UpdatedAt: timestamp(),
+	}
+	m.organizations[org.ID] = org
+	m.members[memberKey(org.ID, userID)] = model.Membership{
+		OrganizationID: org.ID,
+		UserID:         userID,
+		Role:           model.RoleOwner,
+		CreatedAt:      timestamp(),
+	}
+
+	org.Role = model.RoleOwner
+	return org, nil
+}
+
+// GetOrganizations fetches the organizations a user belongs to, along with their role in each
+func (m *Memory) GetOrganizations(ctx context.Context, userID string) (model.Organizations, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orgs := model.Organizations{}
+	for _, member := range m.members {
+		org, ok := m.liveOrganization(member.OrganizationID)
+		if member.UserID != userID || !ok {
+			continue
+		}
+		org.Role = member.Role
+		orgs = append(orgs, org)
+	}
+
+	sort.Slice(orgs, func(i, j int) bool {
+		if orgs[i].CreatedAt.Equal(orgs[j].CreatedAt) {
+			return orgs[i].ID < orgs[j].ID
+		}
+		return orgs[i].CreatedAt.Before(orgs[j].CreatedAt)
+	})
+
+	return orgs, nil
+}
+
+// GetOrganization fetches a single organization the user belongs to, along with their role in it
+func (m *Memory) GetOrganization(ctx context.Context, userID string, orgID string) (model.Organization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	org, ok := m.liveOrganization(orgID)
+	member, isMember := m.members[memberKey(orgID, userID)]
+	if !ok || !isMember {
+		return model.Organization{}, ErrNotFound
+	}
+
+	org.Role = member.Role
+	return org, nil
+}
+
+// DeleteOrganization deletes an organization and its memberships.
+// ErrNotEmpty is returned while the organization still owns resources.
+func (m *Memory) DeleteOrganization(ctx context.Context, orgID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	org, ok := m.liveOrganization(orgID)
+	if !ok {
+		return ErrNotFound
+	}
+
+	for _, t := range registry.Types() {
+		for _, row := range m.rows(t.Name) {
+			owner, _ := columnValue(row, "organization_id")
+			deletedAt, _ := columnValue(row, "deleted_at")
+			if owner != nil && owner.(string) == orgID && !deletedAt.(sql.NullString).Valid {
+				return ErrNotEmpty
+			}
+		}
+	}
+
+	org.DeletedAt = sql.NullString{String: now(), Valid: true}
+	org.UpdatedAt = timestamp()
+	m.organizations[orgID] = org
+
+	for key, member := range m.members {
+		if member.OrganizationID == orgID {
+			delete(m.members, key)
+		}
+	}
+
+	return nil
+}
+
+// GetMembers fetches the members of an organization
+func (m *Memory) GetMembers(ctx context.Context, orgID string) (model.Memberships, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	members := model.Memberships{}
+	for _, member := range m.members {
+		if member.OrganizationID == orgID {
+			members = append(members, m.withUsername(member))
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].CreatedAt.Equal(members[j].CreatedAt) {
+			return members[i].UserID < members[j].UserID
+		}
+		return members[i].CreatedAt.Before(members[j].CreatedAt)
+	})
+
+	return members, nil
+}
+
+// GetMembership fetches a user's membership of a live organization
+func (m *Memory) GetMembership(ctx context.Context, orgID string, userID string) (model.Membership, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	member, ok := m.members[memberKey(orgID, userID)]
+	if _, live := m.liveOrganization(orgID); !ok || !live {
+		return model.Membership{}, ErrNotFound
+	}
+
+	return m.withUsername(member), nil
+}
+
+// AddMember adds a user to an organization. ErrAlreadyExists is returned if they already belong to it.
+func (m *Memory) AddMember(ctx context.Context, member model.Membership) (model.Membership, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.members[memberKey(member.OrganizationID, member.UserID)]; ok {
+		return member, ErrAlreadyExists
+	}
+
+	member.CreatedAt = timestamp()
+	m.members[memberKey(member.OrganizationID, member.UserID)] = member
+
+	return member, nil
+}
+
+// UpdateMember changes a member's role. ErrLastOwner is returned rather than demoting an organization's only owner.
+func (m *Memory) UpdateMember(ctx context.Context, orgID string, userID string, role string) (model.Membership, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	member, ok := m.members[memberKey(orgID, userID)]
+	if _, live := m.liveOrganization(orgID); !ok || !live {
+		return model.Membership{}, ErrNotFound
+	}
+
+	if member.Role == model.RoleOwner && role != model.RoleOwner && m.countOwners(orgID) == 1 {
+		return member, ErrLastOwner
+	}
+
+	member.Role = role
+	m.members[memberKey(orgID, userID)] = member
+
+	return m.withUsername(member), nil
+}
+
+// RemoveMember removes a user from an organization. ErrLastOwner is returned rather than removing its only owner.
+func (m *Memory) RemoveMember(ctx context.Context, orgID string, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	member, ok := m.members[memberKey(orgID, userID)]
+	if _, live := m.liveOrganization(orgID); !ok || !live {
+		return ErrNotFound
+	}
+
+	if member.Role == model.RoleOwner && m.countOwners(orgID) == 1 {
+		return ErrLastOwner
+	}
+
+	delete(m.members, memberKey(orgID, userID))
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+// newTestOrganization creates an organization owned by owner with the other users as members, returning its id
+func newTestOrganization(t *testing.T, m *Memory, owner string, members ...string) string {
+	t.Helper()
+
+	org, err := m.CreateOrganization(context.Background(), owner, "platform")
+	if err != nil {
+		t.Fatalf("CreateOrganization: %s", err)
+	}
+	for _, member := range members {
+		_, err := m.AddMember(context.Background(), model.Membership{OrganizationID: org.ID, UserID: member, Role: model.RoleMember})
+		if err != nil {
+			t.Fatalf("AddMember %s: %s", member, err)
+		}
+	}
+	return org.ID
+}
+
+// createOrgLambda creates a lambda in an organization as userID
+func createOrgLambda(t *testing.T, m *Memory, userID string, orgID string, name string, concurrentLimit int) (interface{}, error) {
+	t.Helper()
+
+	return m.CreateResource(context.Background(), userID, registry.Lambda.Name, registry.WithOrganization(lambdaRow(t, name, concurrentLimit), &orgID))
+}
+
+func TestMemoryOrganizationResourceAccess(t *testing.T) {
+	m := newTestMemory()
+	orgID := newTestOrganization(t, m, "owner-1", "member-1")
+
+	created, err := createOrgLambda(t, m, "owner-1", orgID, "shared-1", 1)
+	if err != nil {
+		t.Fatalf("CreateResource: %s", err)
+	}
+	ID := registry.ID(created)
+
+	tests := []struct {
+		name    string
+		userID  string
+		wantErr error
+	}{
+		{"owner", "owner-1", nil},
+		{"member", "member-1", nil},
+		{"non-member", "outsider-1", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.GetResource(context.Background(), tt.userID, registry.Lambda.Name, ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetResource error = %v, want %v", err, tt.wantErr)
+			}
+
+			listed, _, _ := m.GetResources(context.Background(), tt.userID, registry.Lambda.Name, model.ListOptions{})
+			if want := tt.wantErr == nil; (len(listed) == 1) != want {
+				t.Errorf("listed %v, want the shared lambda listed %t", names(listed), want)
+			}
+
+			_, err = m.UpdateResource(context.Background(), tt.userID, registry.Lambda.Name, ID, registry.WithOrganization(lambdaRow(t, "shared-1", 2), &orgID), nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateResource error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	err = m.RemoveMember(context.Background(), orgID, "member-1")
+	if err != nil {
+		t.Fatalf("RemoveMember: %s", err)
+	}
+	if _, err := m.GetResource(context.Background(), "member-1", registry.Lambda.Name, ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetResource by a removed member error = %v, want ErrNotFound", err)
+	}
+
+	err = m.DeleteResource(context.Background(), "outsider-1", registry.Lambda.Name, ID, nil)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteResource by a non-member error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryOrganizationQuota(t *testing.T) {
+	m := newTestMemory()
+	orgID := newTestOrganization(t, m, "owner-1", "member-1")
+	m.quotas[quotaKey(model.DefaultQuotaUser, registry.Lambda.Name)] = model.Quota{
+		UserID: model.DefaultQuotaUser, ResourceType: registry.Lambda.Name, MaxCount: intPtr(1), MaxCapacity: intPtr(5),
+	}
+
+	created, err := createOrgLambda(t, m, "owner-1", orgID, "shared-1", 4)
+	if err != nil {
+		t.Fatalf("CreateResource: %s", err)
+	}
+
+	// the lambda is charged to the owner who created it, so the member still has room for their own
+	if _, err := createOrgLambda(t, m, "owner-1", orgID, "shared-2", 1); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("second lambda created by the owner error = %v, want ErrQuotaExceeded", err)
+	}
+	if _, err := createOrgLambda(t, m, "member-1", orgID, "shared-2", 1); err != nil {
+		t.Errorf("lambda created by the member error = %v, want nil", err)
+	}
+
+	// changes by another member are checked against the creator's quota
+	_, err = m.UpdateResource(context.Background(), "member-1", registry.Lambda.Name, registry.ID(created), registry.WithOrganization(lambdaRow(t, "shared-1", 6), &orgID), nil)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("member growing the owner's lambda past the owner's quota error = %v, want ErrQuotaExceeded", err)
+	}
+
+	usages, _ := m.GetQuotaUsage(context.Background(), "owner-1")
+	for _, usage := range usages {
+		if usage.ResourceType == registry.Lambda.Name && (usage.Count != 1 || usage.Capacity != 4) {
+			t.Errorf("owner usage = %d lambdas of capacity %d, want 1 of 4", usage.Count, usage.Capacity)
+		}
+	}
+}
+
+func TestMemoryOrganizationMembers(t *testing.T) {
+	m := newTestMemory()
+	orgID := newTestOrganization(t, m, "owner-1", "member-1")
+
+	if _, err := m.GetOrganization(context.Background(), "outsider-1", orgID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOrganization by a non-member error = %v, want ErrNotFound", err)
+	}
+	if org, err := m.GetOrganization(context.Background(), "member-1", orgID); err != nil || org.Role != model.RoleMember {
+		t.Errorf("GetOrganization by a member = %+v, %v, want the member role", org, err)
+	}
+
+	_, err := m.AddMember(context.Background(), model.Membership{OrganizationID: orgID, UserID: "member-1", Role: model.RoleAdmin})
+	if !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("adding an existing member error = %v, want ErrAlreadyExists", err)
+	}
+
+	if _, err := m.UpdateMember(context.Background(), orgID, "owner-1", model.RoleAdmin); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("demoting the last owner error = %v, want ErrLastOwner", err)
+	}
+	if err := m.RemoveMember(context.Background(), orgID, "owner-1"); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("removing the last owner error = %v, want ErrLastOwner", err)
+	}
+
+	if _, err := m.UpdateMember(context.Background(), orgID, "member-1", model.RoleOwner); err != nil {
+		t.Fatalf("UpdateMember: %s", err)
+	}
+	if err := m.RemoveMember(context.Background(), orgID, "owner-1"); err != nil {
+		t.Errorf("removing an owner who isn't the last error = %v, want nil", err)
+	}
+
+	created, err := createOrgLambda(t, m, "member-1", orgID, "shared-1", 1)
+	if err != nil {
+		t.Fatalf("CreateResource: %s", err)
+	}
+	if err := m.DeleteOrganization(context.Background(), orgID); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("DeleteOrganization with resources error = %v, want ErrNotEmpty", err)
+	}
+
+	_ = m.DeleteResource(context.Background(), "member-1", registry.Lambda.Name, registry.ID(created), nil)
+	err = m.TransitionResource(context.Background(), model.ResourceRef{Type: registry.Lambda.Name, ID: registry.ID(created), Status: model.StatusDeleting}, model.StatusDeleted)
+	if err != nil {
+		t.Fatalf("TransitionResource: %s", err)
+	}
+	if err := m.DeleteOrganization(context.Background(), orgID); err != nil {
+		t.Fatalf("DeleteOrganization: %s", err)
+	}
+	if _, err := m.GetMembership(context.Background(), orgID, "member-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetMembership of a deleted organization error = %v, want ErrNotFound", err)
+	}
+}
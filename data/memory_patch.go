@@ -0,0 +1,54 @@
+package data
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+)
+
+// patchRow updates only the given columns of a resource the user can access to their values in row, returning
+// the updated row. When version is set the update only applies if the stored version matches, otherwise
+// ErrVersionMismatch is returned. Resources being deleted can't be patched and return ErrDeleting. Callers must
+// hold m.mu.
+func (m *Memory) patchRow(ctx context.Context, userID string, resourceType string, ID string, row interface{}, columns []string, version *int) (interface{}, error) {
+	stored, ok := m.row(resourceType, ID)
+	if !ok || !m.canAccessRow(userID, stored) {
+		return nil, ErrNotFound
+	}
+
+	if status, _ := columnValue(stored, "status"); status == model.StatusDeleting {
+		return nil, ErrDeleting
+	}
+
+	storedVersion, _ := columnValue(stored, "version")
+	if version != nil && storedVersion.(int) != *version {
+		return nil, ErrVersionMismatch
+	}
+
+	patched := stored
+	for _, column := range columns {
+		value, ok := columnValue(row, column)
+		if !ok {
+			return nil, fmt.Errorf("unable to patch column %s of %s", column, resourceType)
+		}
+		patched = withColumn(patched, column, value)
+	}
+
+	creator, _ := columnValue(stored, "user_id")
+	err := m.checkQuota(ctx, creator.(string), resourceType, ID, patched)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.nameTaken(resourceType, ID, patched)
+	if err != nil {
+		return nil, err
+	}
+
+	patched = withColumn(patched, "version", storedVersion.(int)+1)
+	patched = withColumn(patched, "updated_at", timestamp())
+	m.put(resourceType, patched)
+
+	return patched, nil
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+// quotaKey identifies a user's quota for a resource type
+func quotaKey(userID string, resourceType string) string {
+	return userID + "\x00" + resourceType
+}
+
+// quota returns the quota for a resource type which applies to the user, falling back to the default quota set
+// for all users and then to the one the type declares
+func (m *Memory) quota(userID string, resourceType string) model.Quota {
+	if quota, ok := m.quotas[quotaKey(userID, resourceType)]; ok {
+		return quota
+	}
+	if quota, ok := m.quotas[quotaKey(model.DefaultQuotaUser, resourceType)]; ok {
+		return quota
+	}
+	return defaultQuota(userID, resourceType)
+}
+
+// usage counts a user's live resources of a type and their total capacity, excluding the resource with id exclude,
+// as getUsage does
+func (m *Memory) usage(userID string, t *registry.Type, exclude string) model.QuotaUsage {
+	usage := model.QuotaUsage{ResourceType: t.Name, CapacityUnit: t.CapacityUnit}
+
+	for id, row := range m.rows(t.Name) {
+		owner, _ := columnValue(row, "user_id")
+		deletedAt, _ := columnValue(row, "deleted_at")
+		if id == exclude || owner != userID || deletedAt.(sql.NullString).Valid {
+			continue
+		}
+
+		usage.Count++
+		usage.Capacity += t.RowCapacity(row)
+	}
+
+	return usage
+}
+
+// checkQuota returns ErrQuotaExceeded if the user's quota for the resource type does not allow row. replacing is
+// the id of the resource being updated, or empty when creating. Callers must hold m.mu.
+func (m *Memory) checkQuota(ctx context.Context, userID string, resourceType string, replacing string, row interface{}) error {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return unknownType(resourceType)
+	}
+
+	previous := 0
+	if stored, ok := m.row(resourceType, replacing); ok {
+		previous = t.RowCapacity(stored)
+	}
+
+	err := enforceQuota(m.quota(userID, resourceType), m.usage(userID, t, replacing), replacing == "", previous, t.RowCapacity(row))
+	if err != nil {
+		m.logger.Info(newLog(ctx, "Rejected %s for user %s: %s", resourceType, userID, err.Error()))
+	}
+	return err
+}
+
+// GetQuotaUsage fetches a user's usage of every resource type against their quotas
+func (m *Memory) GetQuotaUsage(ctx context.Context, userID string) (model.QuotaUsages, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	usages := model.QuotaUsages{}
+
+	for _, t := range registry.Types() {
+		quota := m.quota(userID, t.Name)
+
+		usage := m.usage(userID, t, "")
+		usage.MaxCount = quota.MaxCount
+		usage.MaxCapacity = quota.MaxCapacity
+		usages = append(usages, usage)
+	}
+
+	return usages, nil
+}
@@ -0,0 +1,157 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestMemoryQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		quota   model.Quota
+		create  []int
+		update  int
+		wantErr error
+	}{
+		{
+			name:    "count limit",
+			quota:   model.Quota{UserID: "user-1", MaxCount: intPtr(2)},
+			create:  []int{1, 1, 1},
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name:    "capacity limit",
+			quota:   model.Quota{UserID: "user-1", MaxCapacity: intPtr(10)},
+			create:  []int{6, 5},
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name:   "exactly at capacity",
+			quota:  model.Quota{UserID: "user-1", MaxCapacity: intPtr(10)},
+			create: []int{6, 4},
+		},
+		{
+			name:    "update over capacity",
+			quota:   model.Quota{UserID: "user-1", MaxCapacity: intPtr(10)},
+			create:  []int{6},
+			update:  11,
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name:   "update within capacity replaces usage",
+			quota:  model.Quota{UserID: "user-1", MaxCapacity: intPtr(10)},
+			create: []int{6},
+			update: 10,
+		},
+		{
+			name:   "update at the count limit",
+			quota:  model.Quota{UserID: "user-1", MaxCount: intPtr(1)},
+			create: []int{1},
+			update: 2,
+		},
+		{
+			name:    "default quota for every user",
+			quota:   model.Quota{UserID: model.DefaultQuotaUser, MaxCount: intPtr(1)},
+			create:  []int{1, 1},
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name:   "unlimited",
+			quota:  model.Quota{UserID: "user-1"},
+			create: []int{200, 200, 200, 200, 200, 200},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMemory()
+			tt.quota.ResourceType = registry.Lambda.Name
+			m.quotas[quotaKey(tt.quota.UserID, tt.quota.ResourceType)] = tt.quota
+
+			var err error
+			var last interface{}
+			for i, limit := range tt.create {
+				last, err = m.CreateResource(context.Background(), "user-1", registry.Lambda.Name, lambdaRow(t, string(rune('a'+i))+"-lambda", limit))
+				if err != nil {
+					break
+				}
+			}
+			if err == nil && tt.update > 0 {
+				_, err = m.UpdateResource(context.Background(), "user-1", registry.Lambda.Name, registry.ID(last), lambdaRow(t, "a-lambda", tt.update), nil)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMemoryQuotaFallsBackToRegistry(t *testing.T) {
+	m := newTestMemory()
+	createLambda(t, m, "user-1", "alpha-1", 5)
+
+	usages, err := m.GetQuotaUsage(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("GetQuotaUsage: %s", err)
+	}
+
+	for _, usage := range usages {
+		if usage.ResourceType != registry.Lambda.Name {
+			continue
+		}
+
+		want := model.QuotaUsage{
+			ResourceType: registry.Lambda.Name,
+			Count:        1,
+			MaxCount:     intPtr(registry.Lambda.DefaultQuota.MaxCount),
+			Capacity:     5,
+			MaxCapacity:  intPtr(registry.Lambda.DefaultQuota.MaxCapacity),
+			CapacityUnit: registry.Lambda.CapacityUnit,
+		}
+		if usage.Count != want.Count || usage.Capacity != want.Capacity || *usage.MaxCount != *want.MaxCount ||
+			*usage.MaxCapacity != *want.MaxCapacity || usage.CapacityUnit != want.CapacityUnit {
+			t.Errorf("usage = %+v, want %+v", usage, want)
+		}
+		return
+	}
+
+	t.Errorf("no quota usage for %s", registry.Lambda.Name)
+}
+
+func TestMemoryQuotaLoweredBelowUsage(t *testing.T) {
+	m := newTestMemory()
+	created := createLambda(t, m, "user-1", "alpha-1", 8)
+	ID := registry.ID(created)
+
+	m.quotas[quotaKey("user-1", registry.Lambda.Name)] = model.Quota{UserID: "user-1", ResourceType: registry.Lambda.Name, MaxCapacity: intPtr(5)}
+
+	tests := []struct {
+		name     string
+		capacity int
+		wantErr  error
+	}{
+		{"unchanged", 8, nil},
+		{"shrunk but still over", 6, nil},
+		{"grown while over", 7, ErrQuotaExceeded},
+		{"shrunk within", 5, nil},
+		{"grown over again", 6, ErrQuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.PatchResource(context.Background(), "user-1", registry.Lambda.Name, ID, lambdaRow(t, "alpha-1", tt.capacity), []string{"concurrent_limit"}, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+	"github.com/google/uuid"
+)
+
+// nameTaken returns ErrAlreadyExists if a live resource of the type other than the one with id ID has the name of
+// row and the same owner, which is its organization or, for personal resources, its creator. Callers must hold m.mu.
+func (m *Memory) nameTaken(resourceType string, ID string, row interface{}) error {
+	creator, _ := columnValue(row, "user_id")
+	orgID, _ := columnValue(row, "organization_id")
+	name, _ := columnValue(row, "name")
+
+	for id, other := range m.rows(resourceType) {
+		otherCreator, _ := columnValue(other, "user_id")
+		otherOrgID, _ := columnValue(other, "organization_id")
+		otherName, _ := columnValue(other, "name")
+		otherDeletedAt, _ := columnValue(other, "deleted_at")
+
+		if id == ID || otherName != name || otherOrgID != orgID || otherDeletedAt.(sql.NullString).Valid {
+			continue
+		}
+		if orgID != nil || otherCreator == creator {
+			return fmt.Errorf("%w: another %s is named %s", ErrAlreadyExists, resourceType, name)
+		}
+	}
+
+	return nil
+}
+
+// CreateResource creates a resource of a type from the name, fields and tags of row, which must be a row of that
+// type. ErrAlreadyExists is returned if a live resource of the same type and owner already has its name.
+func (m *Memory) CreateResource(ctx context.Context, userID string, resourceType string, row interface{}) (interface{}, error) {
+	if _, ok := registry.Lookup(resourceType); !ok {
+		return nil, unknownType(resourceType)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := m.checkQuota(ctx, userID, resourceType, "", row)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := row
+	if tags, _ := columnValue(row, "tags"); tags.(model.Tags) == nil {
+		stored = withColumn(stored, "tags", model.Tags{})
+	}
+	createdAt := timestamp()
+	stored = withColumn(stored, "id", uuid.New().String())
+	stored = withColumn(stored, "user_id", userID)
+	stored = withColumn(stored, "status", model.StatusPending)
+	stored = withColumn(stored, "version", 1)
+	stored = withColumn(stored, "created_at", createdAt)
+	stored = withColumn(stored, "updated_at", createdAt)
+
+	err = m.nameTaken(resourceType, "", stored)
+	if err != nil {
+		return nil, err
+	}
+
+	m.put(resourceType, stored)
+
+	return stored, nil
+}
+
+// GetResource fetches a single resource of a type owned by the user or one of their organizations
+func (m *Memory) GetResource(ctx context.Context, userID string, resourceType string, ID string) (interface{}, error) {
+	if _, ok := registry.Lookup(resourceType); !ok {
+		return nil, unknownType(resourceType)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	row, ok := m.row(resourceType, ID)
+	if !ok || !m.canAccessRow(userID, row) {
+		return nil, ErrNotFound
+	}
+
+	return row, nil
+}
+
+// GetResources fetches a page of resources of a type owned by a user or their organizations, returning the cursor
+// of the next page if there is one
+func (m *Memory) GetResources(ctx context.Context, userID string, resourceType string, opts model.ListOptions) ([]interface{}, string, error) {
+	if _, ok := registry.Lookup(resourceType); !ok {
+		return nil, "", unknownType(resourceType)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := []interface{}{}
+	for _, r := range m.rows(resourceType) {
+		creator, _ := columnValue(r, "user_id")
+		orgID, _ := columnValue(r, "organization_id")
+		deletedAt, _ := columnValue(r, "deleted_at")
+
+		var org *string
+		if orgID != nil {
+			id := orgID.(string)
+			org = &id
+		}
+
+		if m.canAccess(userID, creator.(string), org) && listed(deletedAt.(sql.NullString), opts) {
+			rows = append(rows, r)
+		}
+	}
+
+	return pageRows(rows, opts)
+}
+
+// UpdateResource replaces the name, fields and tags of an existing resource with those of row, keeping its tags
+// if row has none. When version is set the update only applies if the stored version matches, otherwise
+// ErrVersionMismatch is returned. Resources being deleted can't be updated and return ErrDeleting.
+func (m *Memory) UpdateResource(ctx context.Context, userID string, resourceType string, ID string, row interface{}, version *int) (interface{}, error) {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return nil, unknownType(resourceType)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.row(resourceType, ID)
+	if !ok || !m.canAccessRow(userID, stored) {
+		return nil, ErrNotFound
+	}
+	if status, _ := columnValue(stored, "status"); status == model.StatusDeleting {
+		return nil, ErrDeleting
+	}
+
+	storedVersion, _ := columnValue(stored, "version")
+	if version != nil && storedVersion.(int) != *version {
+		return nil, ErrVersionMismatch
+	}
+
+	creator, _ := columnValue(stored, "user_id")
+	err := m.checkQuota(ctx, creator.(string), resourceType, ID, row)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, column := range writableColumns(t) {
+		value, _ := columnValue(row, column)
+		if tags, ok := value.(model.Tags); ok && tags == nil {
+			continue
+		}
+		stored = withColumn(stored, column, value)
+	}
+
+	err = m.nameTaken(resourceType, ID, stored)
+	if err != nil {
+		return nil, err
+	}
+
+	stored = withColumn(stored, "version", storedVersion.(int)+1)
+	stored = withColumn(stored, "updated_at", timestamp())
+	m.put(resourceType, stored)
+
+	return stored, nil
+}
+
+// PatchResource updates only the given columns of an existing resource to their values in row. When version is set
+// the update only applies if the stored version matches, otherwise ErrVersionMismatch is returned. Resources being
+// deleted can't be patched and return ErrDeleting.
+func (m *Memory) PatchResource(ctx context.Context, userID string, resourceType string, ID string, row interface{}, columns []string, version *int) (interface{}, error) {
+	if _, ok := registry.Lookup(resourceType); !ok {
+		return nil, unknownType(resourceType)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.patchRow(ctx, userID, resourceType, ID, row, columns, version)
+}
+
+// DeleteResource starts destroying an existing resource. When version is set the delete only applies if the
+// stored version matches, otherwise ErrVersionMismatch is returned. Deleting a resource which is already being
+// deleted does nothing, so retries don't postpone its deletion.
+func (m *Memory) DeleteResource(ctx context.Context, userID string, resourceType string, ID string, version *int) error {
+	if _, ok := registry.Lookup(resourceType); !ok {
+		return unknownType(resourceType)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.row(resourceType, ID)
+	if !ok || !m.canAccessRow(userID, stored) {
+		return ErrNotFound
+	}
+	if status, _ := columnValue(stored, "status"); status == model.StatusDeleting {
+		return nil
+	}
+
+	storedVersion, _ := columnValue(stored, "version")
+	if version != nil && storedVersion.(int) != *version {
+		return ErrVersionMismatch
+	}
+
+	stored = withColumn(stored, "status", model.StatusDeleting)
+	stored = withColumn(stored, "version", storedVersion.(int)+1)
+	stored = withColumn(stored, "updated_at", timestamp())
+	m.put(resourceType, stored)
+
+	return nil
+}
@@ -0,0 +1,342 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+// lambdaRow returns a lambda row as decoded from a request body
+func lambdaRow(t *testing.T, name string, concurrentLimit int) interface{} {
+	t.Helper()
+
+	body := registry.Lambda.NewBody()
+	err := json.Unmarshal([]byte(fmt.Sprintf(`{"name": %q, "concurrent_limit": %d}`, name, concurrentLimit)), body)
+	if err != nil {
+		t.Fatalf("Unable to decode lambda body: %s", err)
+	}
+	return registry.Lambda.RowFromBody(body)
+}
+
+func createLambda(t *testing.T, m *Memory, userID string, name string, concurrentLimit int) interface{} {
+	t.Helper()
+
+	row, err := m.CreateResource(context.Background(), userID, registry.Lambda.Name, lambdaRow(t, name, concurrentLimit))
+	if err != nil {
+		t.Fatalf("Unable to create lambda %s: %s", name, err)
+	}
+	return row
+}
+
+func names(rows []interface{}) []string {
+	names := []string{}
+	for _, row := range rows {
+		name, _ := columnValue(row, "name")
+		names = append(names, name.(string))
+	}
+	return names
+}
+
+func TestMemoryGetResourcesPages(t *testing.T) {
+	m := newTestMemory()
+	for i, name := range []string{"beta-1", "alpha-2", "gamma-1", "alpha-1", "beta-2"} {
+		createLambda(t, m, "user-1", name, i+1)
+	}
+	createLambda(t, m, "user-2", "alpha-3", 1)
+
+	tests := []struct {
+		name string
+		opts model.ListOptions
+		want []string
+	}{
+		{
+			name: "sorted by name",
+			opts: model.ListOptions{Sort: "name", Limit: 2},
+			want: []string{"alpha-1", "alpha-2", "beta-1", "beta-2", "gamma-1"},
+		},
+		{
+			name: "descending",
+			opts: model.ListOptions{Sort: "name", Descending: true, Limit: 2},
+			want: []string{"gamma-1", "beta-2", "beta-1", "alpha-2", "alpha-1"},
+		},
+		{
+			name: "single page",
+			opts: model.ListOptions{Sort: "name"},
+			want: []string{"alpha-1", "alpha-2", "beta-1", "beta-2", "gamma-1"},
+		},
+		{
+			name: "prefix filter",
+			opts: model.ListOptions{Sort: "name", Limit: 1, Filters: []model.Filter{
+				{Field: "name", Operator: model.FilterPrefix, Value: "beta"},
+			}},
+			want: []string{"beta-1", "beta-2"},
+		},
+		{
+			name: "range filter",
+			opts: model.ListOptions{Sort: "name", Limit: 2, Filters: []model.Filter{
+				{Field: "concurrent_limit", Operator: model.FilterGreaterOrEqual, Value: int64(2)},
+				{Field: "concurrent_limit", Operator: model.FilterLessOrEqual, Value: int64(4)},
+			}},
+			want: []string{"alpha-1", "alpha-2", "gamma-1"},
+		},
+		{
+			name: "equality filter",
+			opts: model.ListOptions{Filters: []model.Filter{
+				{Field: "name", Operator: model.FilterEquals, Value: "gamma-1"},
+			}},
+			want: []string{"gamma-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			opts := tt.opts
+
+			for pages := 0; ; pages++ {
+				if pages > len(tt.want) {
+					t.Fatalf("listing did not finish after %d pages", pages)
+				}
+
+				rows, next, err := m.GetResources(context.Background(), "user-1", registry.Lambda.Name, opts)
+				if err != nil {
+					t.Fatalf("GetResources: %s", err)
+				}
+				if opts.Limit > 0 && len(rows) > opts.Limit {
+					t.Fatalf("page has %d rows, want at most %d", len(rows), opts.Limit)
+				}
+
+				got = append(got, names(rows)...)
+				if next == "" {
+					break
+				}
+				opts.Cursor = next
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryGetResourcesInvalidOptions(t *testing.T) {
+	m := newTestMemory()
+	createLambda(t, m, "user-1", "alpha-1", 1)
+
+	tests := []struct {
+		name string
+		opts model.ListOptions
+	}{
+		{"malformed cursor", model.ListOptions{Cursor: "not a cursor"}},
+		{"unknown sort field", model.ListOptions{Sort: "colour"}},
+		{"unknown filter field", model.ListOptions{Filters: []model.Filter{{Field: "colour", Operator: model.FilterEquals, Value: "red"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := m.GetResources(context.Background(), "user-1", registry.Lambda.Name, tt.opts)
+			if !errors.Is(err, ErrInvalidListOptions) {
+				t.Errorf("error = %v, want ErrInvalidListOptions", err)
+			}
+		})
+	}
+}
+
+func TestMemoryUpdateResourceVersion(t *testing.T) {
+	stale, current := 1, 2
+
+	tests := []struct {
+		name    string
+		version *int
+		wantErr error
+	}{
+		{"any version", nil, nil},
+		{"current version", &current, nil},
+		{"stale version", &stale, ErrVersionMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMemory()
+			created := createLambda(t, m, "user-1", "alpha-1", 1)
+			ID := registry.ID(created)
+
+			// bring the resource to version 2 so version 1 is stale
+			_, err := m.UpdateResource(context.Background(), "user-1", registry.Lambda.Name, ID, lambdaRow(t, "alpha-1", 2), nil)
+			if err != nil {
+				t.Fatalf("UpdateResource: %s", err)
+			}
+
+			updated, err := m.UpdateResource(context.Background(), "user-1", registry.Lambda.Name, ID, lambdaRow(t, "alpha-1", 3), tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if registry.Version(updated) != 3 {
+				t.Errorf("version = %d, want 3", registry.Version(updated))
+			}
+		})
+	}
+}
+
+func TestMemoryTransitionResourceKeepsVersion(t *testing.T) {
+	m := newTestMemory()
+	created := createLambda(t, m, "user-1", "alpha-1", 1)
+	ID := registry.ID(created)
+	version := registry.Version(created)
+
+	err := m.TransitionResource(context.Background(), model.ResourceRef{Type: registry.Lambda.Name, ID: ID, Status: model.StatusPending}, model.StatusProvisioning)
+	if err != nil {
+		t.Fatalf("TransitionResource: %s", err)
+	}
+
+	// the version read before provisioning started is still current
+	_, err = m.UpdateResource(context.Background(), "user-1", registry.Lambda.Name, ID, lambdaRow(t, "alpha-1", 2), &version)
+	if err != nil {
+		t.Errorf("UpdateResource after a status transition error = %v, want nil", err)
+	}
+}
+
+func TestMemoryChangeDeletingResource(t *testing.T) {
+	m := newTestMemory()
+	created := createLambda(t, m, "user-1", "alpha-1", 1)
+	ID := registry.ID(created)
+
+	err := m.DeleteResource(context.Background(), "user-1", registry.Lambda.Name, ID, nil)
+	if err != nil {
+		t.Fatalf("DeleteResource: %s", err)
+	}
+	deleting, _ := m.GetResource(context.Background(), "user-1", registry.Lambda.Name, ID)
+
+	_, err = m.UpdateResource(context.Background(), "user-1", registry.Lambda.Name, ID, lambdaRow(t, "alpha-1", 2), nil)
+	if !errors.Is(err, ErrDeleting) {
+		t.Errorf("UpdateResource error = %v, want ErrDeleting", err)
+	}
+
+	_, err = m.PatchResource(context.Background(), "user-1", registry.Lambda.Name, ID, lambdaRow(t, "alpha-1", 2), []string{"concurrent_limit"}, nil)
+	if !errors.Is(err, ErrDeleting) {
+		t.Errorf("PatchResource error = %v, want ErrDeleting", err)
+	}
+
+	err = m.DeleteResource(context.Background(), "user-1", registry.Lambda.Name, ID, nil)
+	if err != nil {
+		t.Errorf("repeated DeleteResource error = %v, want nil", err)
+	}
+	after, _ := m.GetResource(context.Background(), "user-1", registry.Lambda.Name, ID)
+	if !reflect.DeepEqual(after, deleting) {
+		t.Errorf("repeated delete changed the resource from %+v to %+v", deleting, after)
+	}
+}
+
+func TestMemoryPatchResourceColumns(t *testing.T) {
+	m := newTestMemory()
+	created := createLambda(t, m, "user-1", "alpha-1", 1)
+	ID := registry.ID(created)
+
+	patched, err := m.PatchResource(context.Background(), "user-1", registry.Lambda.Name, ID, lambdaRow(t, "ignored", 7), []string{"concurrent_limit"}, nil)
+	if err != nil {
+		t.Fatalf("PatchResource: %s", err)
+	}
+
+	name, _ := columnValue(patched, "name")
+	limit, _ := columnValue(patched, "concurrent_limit")
+	if name != "alpha-1" || limit != 7 {
+		t.Errorf("patched name, concurrent_limit = %v, %v, want alpha-1, 7", name, limit)
+	}
+	if registry.Version(patched) != 2 {
+		t.Errorf("version = %d, want 2", registry.Version(patched))
+	}
+}
+
+func TestMemoryResourceNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		write   func(m *Memory, t *testing.T, ID string) error
+		wantErr error
+	}{
+		{
+			name: "create with a taken name",
+			write: func(m *Memory, t *testing.T, ID string) error {
+				_, err := m.CreateResource(context.Background(), "user-1", registry.Lambda.Name, lambdaRow(t, "alpha-1", 1))
+				return err
+			},
+			wantErr: ErrAlreadyExists,
+		},
+		{
+			name: "another user creates the same name",
+			write: func(m *Memory, t *testing.T, ID string) error {
+				_, err := m.CreateResource(context.Background(), "user-2", registry.Lambda.Name, lambdaRow(t, "alpha-1", 1))
+				return err
+			},
+		},
+		{
+			name: "update to a taken name",
+			write: func(m *Memory, t *testing.T, ID string) error {
+				_, err := m.UpdateResource(context.Background(), "user-1", registry.Lambda.Name, ID, lambdaRow(t, "alpha-1", 1), nil)
+				return err
+			},
+			wantErr: ErrAlreadyExists,
+		},
+		{
+			name: "patch to a taken name",
+			write: func(m *Memory, t *testing.T, ID string) error {
+				_, err := m.PatchResource(context.Background(), "user-1", registry.Lambda.Name, ID, lambdaRow(t, "alpha-1", 1), []string{"name"}, nil)
+				return err
+			},
+			wantErr: ErrAlreadyExists,
+		},
+		{
+			name: "update keeping its own name",
+			write: func(m *Memory, t *testing.T, ID string) error {
+				_, err := m.UpdateResource(context.Background(), "user-1", registry.Lambda.Name, ID, lambdaRow(t, "beta-1", 5), nil)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMemory()
+			createLambda(t, m, "user-1", "alpha-1", 1)
+			other := createLambda(t, m, "user-1", "beta-1", 1)
+
+			err := tt.write(m, t, registry.ID(other))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMemoryRestoreResourceNameTaken(t *testing.T) {
+	m := newTestMemory()
+	deleted := createLambda(t, m, "user-1", "alpha-1", 1)
+	ID := registry.ID(deleted)
+
+	err := m.DeleteResource(context.Background(), "user-1", registry.Lambda.Name, ID, nil)
+	if err != nil {
+		t.Fatalf("DeleteResource: %s", err)
+	}
+	err = m.TransitionResource(context.Background(), model.ResourceRef{Type: registry.Lambda.Name, ID: ID, Status: model.StatusDeleting}, model.StatusDeleted)
+	if err != nil {
+		t.Fatalf("TransitionResource: %s", err)
+	}
+
+	createLambda(t, m, "user-1", "alpha-1", 1)
+
+	err = m.RestoreResource(context.Background(), "user-1", registry.Lambda.Name, ID, time.Hour)
+	if !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("RestoreResource error = %v, want ErrAlreadyExists", err)
+	}
+}
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+// GetResourcesByStatus fetches resources of every type in a status which have not changed for at least the given age
+func (m *Memory) GetResourcesByStatus(ctx context.Context, status string, age time.Duration) ([]model.ResourceRef, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cutoff := time.Now().Add(-age)
+	refs := []model.ResourceRef{}
+
+	for _, t := range registry.Types() {
+		for id, row := range m.rows(t.Name) {
+			rowStatus, _ := columnValue(row, "status")
+			updatedAt, _ := columnValue(row, "updated_at")
+			deletedAt, _ := columnValue(row, "deleted_at")
+
+			if rowStatus != status || updatedAt.(time.Time).After(cutoff) || deletedAt.(sql.NullString).Valid {
+				continue
+			}
+
+			refs = append(refs, model.ResourceRef{Type: t.Name, ID: id, Status: status})
+		}
+	}
+
+	return refs, nil
+}
+
+// TransitionResource moves a resource from its current status to another.
+// ErrNotFound is returned if the resource is no longer in the status held by ref.
+func (m *Memory) TransitionResource(ctx context.Context, ref model.ResourceRef, status string) error {
+	if _, ok := registry.Lookup(ref.Type); !ok {
+		return unknownType(ref.Type)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	row, ok := m.row(ref.Type, ref.ID)
+	if !ok {
+		return ErrNotFound
+	}
+
+	rowStatus, _ := columnValue(row, "status")
+	deletedAt, _ := columnValue(row, "deleted_at")
+	if rowStatus != ref.Status || deletedAt.(sql.NullString).Valid {
+		return ErrNotFound
+	}
+
+	row = withColumn(row, "status", status)
+	row = withColumn(row, "updated_at", timestamp())
+	if status == model.StatusDeleted {
+		row = withColumn(row, "deleted_at", sql.NullString{String: now(), Valid: true})
+	}
+	m.put(ref.Type, row)
+
+	m.logger.Debug(newLog(ctx, "Moved %s %s from %s to %s", ref.Type, ref.ID, ref.Status, status))
+	return nil
+}
+
+// CountResources counts the live resources of every type by status
+func (m *Memory) CountResources(ctx context.Context) ([]model.ResourceCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := []model.ResourceCount{}
+
+	for _, t := range registry.Types() {
+		byStatus := map[string]int{}
+		for _, row := range m.rows(t.Name) {
+			status, _ := columnValue(row, "status")
+			deletedAt, _ := columnValue(row, "deleted_at")
+			if !deletedAt.(sql.NullString).Valid {
+				byStatus[status.(string)]++
+			}
+		}
+
+		for status, count := range byStatus {
+			counts = append(counts, model.ResourceCount{Type: t.Name, Status: status, Count: count})
+		}
+	}
+
+	return counts, nil
+}
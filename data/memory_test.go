@@ -0,0 +1,94 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+func newTestMemory() *Memory {
+	return NewMemory(logs.NewStdLogger(logs.LogLevelFatal)).(*Memory)
+}
+
+func TestMemoryUsers(t *testing.T) {
+	m := newTestMemory()
+
+	created, err := m.CreateUser(context.Background(), "alice", "s3cret")
+	if err != nil {
+		t.Fatalf("CreateUser: %s", err)
+	}
+	if m.users[created.ID].Password == "s3cret" {
+		t.Errorf("password stored in plain text")
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantErr  bool
+	}{
+		{"correct password", "alice", "s3cret", false},
+		{"wrong password", "alice", "secret", true},
+		{"unknown user", "bob", "s3cret", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := m.AuthenticateUser(context.Background(), tt.username, tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && user.ID != created.ID {
+				t.Errorf("authenticated as %s, want %s", user.ID, created.ID)
+			}
+		})
+	}
+
+	_, err = m.CreateUser(context.Background(), "alice", "other")
+	if err == nil {
+		t.Errorf("created a second user named alice")
+	}
+
+	if err := m.DeleteUser(context.Background(), created.ID); err != nil {
+		t.Fatalf("DeleteUser: %s", err)
+	}
+	_, err = m.CreateUser(context.Background(), "alice", "other")
+	if !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("reusing a deleted user's name error = %v, want ErrAlreadyExists", err)
+	}
+}
+
+func TestMemoryResourcesScopedToUser(t *testing.T) {
+	m := newTestMemory()
+	created := createLambda(t, m, "user-1", "alpha", 1)
+	ID := registry.ID(created)
+
+	others, _, _ := m.GetResources(context.Background(), "user-2", registry.Lambda.Name, model.ListOptions{})
+	if len(others) != 0 {
+		t.Errorf("user-2 sees %d lambdas, want 0", len(others))
+	}
+
+	_ = m.DeleteResource(context.Background(), "user-2", registry.Lambda.Name, ID, nil)
+	if _, err := m.GetResource(context.Background(), "user-1", registry.Lambda.Name, ID); err != nil {
+		t.Fatalf("lambda deleted by another user: %s", err)
+	}
+
+	_ = m.DeleteResource(context.Background(), "user-1", registry.Lambda.Name, ID, nil)
+	err := m.TransitionResource(context.Background(), model.ResourceRef{Type: registry.Lambda.Name, ID: ID, Status: model.StatusDeleting}, model.StatusDeleted)
+	if err != nil {
+		t.Fatalf("TransitionResource: %s", err)
+	}
+	if _, err := m.GetResource(context.Background(), "user-1", registry.Lambda.Name, ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetResource of deleted lambda error = %v, want ErrNotFound", err)
+	}
+
+	stored, _ := m.row(registry.Lambda.Name, ID)
+	if deletedAt, _ := columnValue(stored, "deleted_at"); !deletedAt.(sql.NullString).Valid {
+		t.Errorf("lambda removed instead of soft deleted")
+	}
+}
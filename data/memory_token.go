@@ -0,0 +1,105 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/google/uuid"
+)
+
+// CreateRefreshToken stores the hash of a new refresh token for a user, granting scopes and expiring after ttl
+func (m *Memory) CreateRefreshToken(ctx context.Context, userID string, tokenHash string, scopes []string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for hash, token := range m.refreshTokens {
+		if token.UserID == userID && token.ExpiresAt <= now() {
+			delete(m.refreshTokens, hash)
+		}
+	}
+
+	m.insertRefreshToken(userID, tokenHash, scopes, ttl)
+	return nil
+}
+
+func (m *Memory) insertRefreshToken(userID string, tokenHash string, scopes []string, ttl time.Duration) model.RefreshToken {
+	token := model.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		TokenHash: tokenHash,
+		Scopes:    scopes,
+		CreatedAt: timestamp(),
+		ExpiresAt: time.Now().Add(ttl).UTC().Format(timestampLayout),
+	}
+	m.refreshTokens[tokenHash] = token
+	return token
+}
+
+// activeRefreshToken returns a refresh token which has not expired or been revoked
+func (m *Memory) activeRefreshToken(tokenHash string) (model.RefreshToken, bool) {
+	token, ok := m.refreshTokens[tokenHash]
+	if !ok || token.RevokedAt.Valid || token.ExpiresAt <= now() {
+		return model.RefreshToken{}, false
+	}
+	return token, true
+}
+
+// RotateRefreshToken revokes an active refresh token and replaces it with a new one for the same user and scopes,
+// so each refresh token can only be used once. ErrNotFound is returned if the token is unknown, expired or revoked.
+func (m *Memory) RotateRefreshToken(ctx context.Context, tokenHash string, newTokenHash string, ttl time.Duration) (model.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.activeRefreshToken(tokenHash)
+	if !ok {
+		return model.RefreshToken{}, ErrNotFound
+	}
+
+	token.RevokedAt = sql.NullString{String: now(), Valid: true}
+	m.refreshTokens[tokenHash] = token
+
+	return m.insertRefreshToken(token.UserID, newTokenHash, token.Scopes, ttl), nil
+}
+
+// RevokeRefreshToken revokes an active refresh token held by a user.
+// ErrNotFound is returned if the user holds no such token.
+func (m *Memory) RevokeRefreshToken(ctx context.Context, userID string, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.activeRefreshToken(tokenHash)
+	if !ok || token.UserID != userID {
+		return ErrNotFound
+	}
+
+	token.RevokedAt = sql.NullString{String: now(), Valid: true}
+	m.refreshTokens[tokenHash] = token
+
+	return nil
+}
+
+// RevokeToken adds an access token's ID to the revocation list until the token would have expired anyway
+func (m *Memory) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, expires := range m.revokedTokens {
+		if !expires.After(time.Now()) {
+			delete(m.revokedTokens, id)
+		}
+	}
+
+	m.revokedTokens[jti] = expiresAt
+	return nil
+}
+
+// IsTokenRevoked checks the revocation list for an access token's ID
+func (m *Memory) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, revoked := m.revokedTokens[jti]
+	return revoked, nil
+}
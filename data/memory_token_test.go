@@ -0,0 +1,162 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+)
+
+func TestMemoryRotateRefreshToken(t *testing.T) {
+	scopes := []string{"lambdas:read"}
+
+	tests := []struct {
+		name    string
+		setup   func(m *Memory)
+		rotate  string
+		wantErr error
+	}{
+		{
+			name:   "active token",
+			rotate: "first",
+		},
+		{
+			name: "reused token",
+			setup: func(m *Memory) {
+				m.RotateRefreshToken(context.Background(), "first", "second", time.Hour)
+			},
+			rotate:  "first",
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "replacement token",
+			setup: func(m *Memory) {
+				m.RotateRefreshToken(context.Background(), "first", "second", time.Hour)
+			},
+			rotate: "second",
+		},
+		{
+			name: "revoked token",
+			setup: func(m *Memory) {
+				m.RevokeRefreshToken(context.Background(), "user-1", "first")
+			},
+			rotate:  "first",
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "expired token",
+			setup: func(m *Memory) {
+				m.CreateRefreshToken(context.Background(), "user-1", "expired", scopes, -time.Second)
+			},
+			rotate:  "expired",
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "unknown token",
+			rotate:  "unknown",
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMemory()
+			m.CreateRefreshToken(context.Background(), "user-1", "first", scopes, time.Hour)
+			if tt.setup != nil {
+				tt.setup(m)
+			}
+
+			token, err := m.RotateRefreshToken(context.Background(), tt.rotate, "next", time.Hour)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if token.UserID != "user-1" || token.TokenHash != "next" || !reflect.DeepEqual([]string(token.Scopes), scopes) {
+				t.Errorf("rotated token = %+v, want user-1 holding next with scopes %v", token, scopes)
+			}
+
+			_, err = m.RotateRefreshToken(context.Background(), tt.rotate, "again", time.Hour)
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("second rotation error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestMemoryRevokeRefreshTokenOfOtherUser(t *testing.T) {
+	m := newTestMemory()
+	m.CreateRefreshToken(context.Background(), "user-1", "first", nil, time.Hour)
+
+	err := m.RevokeRefreshToken(context.Background(), "user-2", "first")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound", err)
+	}
+
+	_, err = m.RotateRefreshToken(context.Background(), "first", "next", time.Hour)
+	if err != nil {
+		t.Errorf("token revoked by another user can no longer be rotated: %s", err)
+	}
+}
+
+func TestMemoryUserRevocation(t *testing.T) {
+	tests := []struct {
+		name         string
+		action       func(m *Memory, userID string) error
+		wantKeysKept bool
+	}{
+		{"disable", func(m *Memory, userID string) error {
+			return m.SetUserDisabled(context.Background(), userID, true)
+		}, false},
+		{"delete", func(m *Memory, userID string) error {
+			return m.DeleteUser(context.Background(), userID)
+		}, false},
+		{"reset password", func(m *Memory, userID string) error {
+			return m.ResetPassword(context.Background(), userID, "temporary")
+		}, false},
+		{"change password", func(m *Memory, userID string) error {
+			return m.ChangePassword(context.Background(), userID, "changed")
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMemory()
+			user, err := m.CreateUser(context.Background(), "alice", "s3cret")
+			if err != nil {
+				t.Fatalf("CreateUser: %s", err)
+			}
+			m.CreateRefreshToken(context.Background(), user.ID, "refresh", nil, time.Hour)
+			_, err = m.CreateAPIKey(context.Background(), model.APIKey{UserID: user.ID, Name: "ci", KeyHash: "key"})
+			if err != nil {
+				t.Fatalf("CreateAPIKey: %s", err)
+			}
+
+			before := now()
+			if err := tt.action(m, user.ID); err != nil {
+				t.Fatalf("%s: %s", tt.name, err)
+			}
+
+			if revokedAt := m.users[user.ID].TokensRevokedAt; !revokedAt.Valid || revokedAt.String < before {
+				t.Errorf("tokens revoked at %+v, want at or after %s", revokedAt, before)
+			}
+
+			if _, err := m.RotateRefreshToken(context.Background(), "refresh", "next", time.Hour); !errors.Is(err, ErrNotFound) {
+				t.Errorf("rotating the refresh token error = %v, want ErrNotFound", err)
+			}
+
+			_, err = m.AuthenticateAPIKey(context.Background(), "key")
+			if tt.wantKeysKept && err != nil {
+				t.Errorf("API key was revoked: %s", err)
+			}
+			if !tt.wantKeysKept && !errors.Is(err, ErrNotFound) {
+				t.Errorf("authenticating the API key error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
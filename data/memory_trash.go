@@ -0,0 +1,96 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+// listed reports whether a row deleted at deletedAt belongs in a list, mirroring listQuery
+func listed(deletedAt sql.NullString, opts model.ListOptions) bool {
+	if opts.DeletedWithin > 0 {
+		return deletedAt.Valid && deletedAt.String > time.Now().Add(-opts.DeletedWithin).UTC().Format(timestampLayout)
+	}
+	return !deletedAt.Valid
+}
+
+// RestoreResource brings back a resource the user can access which was deleted within the retention period, so it
+// is provisioned again. The creator's quota is checked as if the resource were being created, and ErrAlreadyExists
+// is returned if a live resource of the same type and owner has since taken its name.
+func (m *Memory) RestoreResource(ctx context.Context, userID string, resourceType string, ID string, retention time.Duration) error {
+	if _, ok := registry.Lookup(resourceType); !ok {
+		return unknownType(resourceType)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	row, ok := m.row(resourceType, ID)
+	if !ok {
+		return ErrNotFound
+	}
+
+	creator, _ := columnValue(row, "user_id")
+	orgID, _ := columnValue(row, "organization_id")
+	deletedAt, _ := columnValue(row, "deleted_at")
+
+	var org *string
+	if orgID != nil {
+		id := orgID.(string)
+		org = &id
+		if _, ok := m.liveOrganization(id); !ok {
+			return ErrNotFound
+		}
+	}
+
+	if !m.canAccess(userID, creator.(string), org) || !listed(deletedAt.(sql.NullString), model.ListOptions{DeletedWithin: retention}) {
+		return ErrNotFound
+	}
+
+	err := m.checkQuota(ctx, creator.(string), resourceType, "", row)
+	if err != nil {
+		return err
+	}
+
+	err = m.nameTaken(resourceType, ID, row)
+	if err != nil {
+		return err
+	}
+
+	row = withColumn(row, "status", model.StatusPending)
+	row = withColumn(row, "updated_at", timestamp())
+	version, _ := columnValue(row, "version")
+	row = withColumn(row, "version", version.(int)+1)
+	row = withColumn(row, "deleted_at", sql.NullString{})
+	m.put(resourceType, row)
+
+	m.logger.Debug(newLog(ctx, "Restored %s %s", resourceType, ID))
+	return nil
+}
+
+// PurgeResources permanently removes resources of every type which were deleted longer ago than retention
+func (m *Memory) PurgeResources(ctx context.Context, retention time.Duration) ([]model.ResourceRef, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := time.Now().Add(-retention).UTC().Format(timestampLayout)
+	refs := []model.ResourceRef{}
+
+	for _, t := range registry.Types() {
+		for id, row := range m.rows(t.Name) {
+			status, _ := columnValue(row, "status")
+			deletedAt, _ := columnValue(row, "deleted_at")
+			if !deletedAt.(sql.NullString).Valid || deletedAt.(sql.NullString).String > cutoff {
+				continue
+			}
+
+			m.remove(t.Name, id)
+			refs = append(refs, model.ResourceRef{Type: t.Name, ID: id, Status: status.(string)})
+		}
+	}
+
+	return refs, nil
+}
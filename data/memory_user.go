@@ -0,0 +1,220 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// withoutPassword returns a copy of a user without their password hash
+func withoutPassword(u model.User) model.User {
+	u.Password = ""
+	return u
+}
+
+// CreateUser creates a new user with a bcrypt hashed password
+func (m *Memory) CreateUser(ctx context.Context, username string, password string) (model.User, error) {
+	m.logger.Verbose(newLog(ctx, "Create user called"))
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		m.logger.Error(newLog(ctx, "Error hashing password: %s", err.Error()))
+		return model.User{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// deleted users keep their username, as it stays unique in the users table
+	for _, u := range m.users {
+		if u.Username == username {
+			m.logger.Info(newLog(ctx, "Error creating user: username %s already exists", username))
+			return model.User{}, fmt.Errorf("%w: username %s is taken", ErrAlreadyExists, username)
+		}
+	}
+
+	user := model.User{
+		ID:        uuid.New().String(),
+		Username:  username,
+		Password:  string(hash),
+		CreatedAt: timestamp(),
+		UpdatedAt: timestamp(),
+	}
+	m.users[user.ID] = user
+
+	m.logger.Info(newLog(ctx, "Created user %s", user.ID))
+	return withoutPassword(user), nil
+}
+
+// AuthenticateUser ensures username and password match and returns result
+func (m *Memory) AuthenticateUser(ctx context.Context, username string, password string) (model.User, error) {
+	m.logger.Verbose(newLog(ctx, "Authenticate user called"))
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.users {
+		if u.Username != username || u.DeletedAt.Valid {
+			continue
+		}
+		if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+			break
+		}
+
+		m.logger.Info(newLog(ctx, "User %s found", u.Username))
+		return withoutPassword(u), nil
+	}
+
+	m.logger.Info(newLog(ctx, "User not found"))
+	return model.User{}, errors.New("User not found")
+}
+
+// GetUser fetches a single user by id
+func (m *Memory) GetUser(ctx context.Context, userID string) (model.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.DeletedAt.Valid {
+		return model.User{}, ErrNotFound
+	}
+
+	return withoutPassword(u), nil
+}
+
+// GetUserByUsername fetches a single user by username
+func (m *Memory) GetUserByUsername(ctx context.Context, username string) (model.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.users {
+		if u.Username == username && !u.DeletedAt.Valid {
+			return withoutPassword(u), nil
+		}
+	}
+
+	return model.User{}, ErrNotFound
+}
+
+// GetUsers fetches a page of live users, returning the cursor of the next page if there is one
+func (m *Memory) GetUsers(ctx context.Context, opts model.ListOptions) (model.Users, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rows := []interface{}{}
+	for _, u := range m.users {
+		if !u.DeletedAt.Valid {
+			rows = append(rows, withoutPassword(u))
+		}
+	}
+
+	page, next, err := pageRows(rows, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	users := model.Users{}
+	for _, r := range page {
+		users = append(users, r.(model.User))
+	}
+
+	return users, next, nil
+}
+
+// SetUserDisabled disables or re-enables a user. Disabling a user also revokes all of their tokens and API keys.
+func (m *Memory) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	revoke := revokeNothing
+	if disabled {
+		revoke = revokeAll
+	}
+
+	return m.updateUser(userID, revoke, func(u *model.User) {
+		u.DisabledAt = sql.NullString{}
+		if disabled {
+			u.DisabledAt = sql.NullString{String: now(), Valid: true}
+		}
+	})
+}
+
+// DeleteUser soft deletes a user and revokes all of their tokens and API keys
+func (m *Memory) DeleteUser(ctx context.Context, userID string) error {
+	return m.updateUser(userID, revokeAll, func(u *model.User) {
+		u.DeletedAt = sql.NullString{String: now(), Valid: true}
+	})
+}
+
+// ResetPassword replaces a user's password with a temporary one they must change,
+// revoking all of their tokens and API keys
+func (m *Memory) ResetPassword(ctx context.Context, userID string, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	return m.updateUser(userID, revokeAll, func(u *model.User) {
+		u.Password = string(hash)
+		u.PasswordResetRequired = true
+	})
+}
+
+// ChangePassword sets a user's password and clears any required reset. Every token issued to the user so far and all
+// of their refresh tokens are revoked, but their API keys are kept.
+func (m *Memory) ChangePassword(ctx context.Context, userID string, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	return m.updateUser(userID, revokeSessions, func(u *model.User) {
+		u.Password = string(hash)
+		u.PasswordResetRequired = false
+	})
+}
+
+// updateUser applies update to a live user and revokes their credentials as far as revoke asks.
+// ErrNotFound is returned if there is no such user.
+func (m *Memory) updateUser(userID string, revoke revocation, update func(u *model.User)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.DeletedAt.Valid {
+		return ErrNotFound
+	}
+
+	update(&u)
+	u.UpdatedAt = timestamp()
+	if revoke >= revokeSessions {
+		u.TokensRevokedAt = sql.NullString{String: now(), Valid: true}
+	}
+	m.users[userID] = u
+
+	if revoke < revokeSessions {
+		return nil
+	}
+
+	for hash, token := range m.refreshTokens {
+		if token.UserID == userID && !token.RevokedAt.Valid {
+			token.RevokedAt = sql.NullString{String: now(), Valid: true}
+			m.refreshTokens[hash] = token
+		}
+	}
+
+	if revoke < revokeAll {
+		return nil
+	}
+
+	for id, key := range m.apiKeys {
+		if key.UserID == userID && !key.RevokedAt.Valid {
+			key.RevokedAt = sql.NullString{String: now(), Valid: true}
+			m.apiKeys[id] = key
+		}
+	}
+
+	return nil
+}
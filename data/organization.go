@@ -0,0 +1,264 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// accessibleBy is the condition matching resources a user can access: their personal resources, and those
+// of every organization they belong to. param is the placeholder holding the user's id.
+func accessibleBy(param string) string {
+	return fmt.Sprintf(`(organization_id IS NULL AND user_id = %[1]s
+		OR organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = %[1]s))`, param)
+}
+
+// resourceCreator fetches the user who created a resource the user can access, as quotas are charged to them
+func (c *PostgresSQL) resourceCreator(ctx context.Context, table string, userID string, id string) (string, error) {
+	creator := ""
+
+	err := c.db.GetContext(ctx, &creator, fmt.Sprintf(
+		`SELECT user_id FROM %s WHERE id = $1 AND %s AND deleted_at IS NULL`, table, accessibleBy("$2")),
+		id, userID)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+
+	return creator, err
+}
+
+// CreateOrganization creates a new organization with the user as its owner
+func (c *PostgresSQL) CreateOrganization(ctx context.Context, userID string, name string) (model.Organization, error) {
+	org := model.Organization{}
+
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return org, err
+	}
+	defer tx.Rollback()
+
+	err = tx.GetContext(ctx, &org,
+		`INSERT INTO organizations (id, name, created_at, updated_at) VALUES ($1, $2, now(), now()) RETURNING *`,
+		uuid.New().String(), name)
+	if err != nil {
+		return org, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO organization_members (organization_id, user_id, role, created_at) VALUES ($1, $2, $3, now())`,
+		org.ID, userID, model.RoleOwner)
+	if err != nil {
+		return org, err
+	}
+
+	org.Role = model.RoleOwner
+	return org, tx.Commit()
+}
+
+// GetOrganizations fetches the organizations a user belongs to, along with their role in each
+func (c *PostgresSQL) GetOrganizations(ctx context.Context, userID string) (model.Organizations, error) {
+	orgs := model.Organizations{}
+
+	err := c.db.SelectContext(ctx, &orgs,
+		`SELECT o.*, m.role FROM organizations o JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = $1 AND o.deleted_at IS NULL ORDER BY o.created_at, o.id`, userID)
+
+	return orgs, err
+}
+
+// GetOrganization fetches a single organization the user belongs to, along with their role in it
+func (c *PostgresSQL) GetOrganization(ctx context.Context, userID string, orgID string) (model.Organization, error) {
+	org := model.Organization{}
+
+	err := c.db.GetContext(ctx, &org,
+		`SELECT o.*, m.role FROM organizations o JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = $1 AND o.id = $2 AND o.deleted_at IS NULL`, userID, orgID)
+	if err == sql.ErrNoRows {
+		return org, ErrNotFound
+	}
+
+	return org, err
+}
+
+// DeleteOrganization deletes an organization and its memberships.
+// ErrNotEmpty is returned while the organization still owns resources.
+func (c *PostgresSQL) DeleteOrganization(ctx context.Context, orgID string) error {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockOrganization(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+
+	owned := []string{}
+	for _, t := range registry.Types() {
+		owned = append(owned, fmt.Sprintf(`SELECT 1 FROM %s WHERE organization_id = $1 AND deleted_at IS NULL`, t.Table))
+	}
+
+	empty := false
+	err = tx.GetContext(ctx, &empty, fmt.Sprintf(`SELECT NOT EXISTS (%s)`, strings.Join(owned, " UNION ALL ")), orgID)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return ErrNotEmpty
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE organizations SET deleted_at = now(), updated_at = now() WHERE id = $1`, orgID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM organization_members WHERE organization_id = $1`, orgID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetMembers fetches the members of an organization
+func (c *PostgresSQL) GetMembers(ctx context.Context, orgID string) (model.Memberships, error) {
+	members := model.Memberships{}
+
+	err := c.db.SelectContext(ctx, &members,
+		`SELECT m.*, u.username FROM organization_members m JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1 ORDER BY m.created_at, m.user_id`, orgID)
+
+	return members, err
+}
+
+// GetMembership fetches a user's membership of a live organization
+func (c *PostgresSQL) GetMembership(ctx context.Context, orgID string, userID string) (model.Membership, error) {
+	member := model.Membership{}
+
+	err := c.db.GetContext(ctx, &member,
+		`SELECT m.*, u.username FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+		JOIN organizations o ON o.id = m.organization_id
+		WHERE m.organization_id = $1 AND m.user_id = $2 AND o.deleted_at IS NULL`, orgID, userID)
+	if err == sql.ErrNoRows {
+		return member, ErrNotFound
+	}
+
+	return member, err
+}
+
+// AddMember adds a user to an organization. ErrAlreadyExists is returned if they already belong to it.
+func (c *PostgresSQL) AddMember(ctx context.Context, member model.Membership) (model.Membership, error) {
+	res, err := c.db.ExecContext(ctx,
+		`INSERT INTO organization_members (organization_id, user_id, role, created_at) VALUES ($1, $2, $3, now())
+		ON CONFLICT (organization_id, user_id) DO NOTHING`,
+		member.OrganizationID, member.UserID, member.Role)
+	if err != nil {
+		return member, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return member, err
+	}
+	if affected == 0 {
+		return member, ErrAlreadyExists
+	}
+
+	return member, nil
+}
+
+// UpdateMember changes a member's role. ErrLastOwner is returned rather than demoting an organization's only owner.
+func (c *PostgresSQL) UpdateMember(ctx context.Context, orgID string, userID string, role string) (model.Membership, error) {
+	member := model.Membership{}
+
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return member, err
+	}
+	defer tx.Rollback()
+
+	owners, err := lockOrganization(ctx, tx, orgID)
+	if err != nil {
+		return member, err
+	}
+
+	err = tx.GetContext(ctx, &member,
+		`SELECT m.*, u.username FROM organization_members m JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1 AND m.user_id = $2`, orgID, userID)
+	if err == sql.ErrNoRows {
+		return member, ErrNotFound
+	}
+	if err != nil {
+		return member, err
+	}
+
+	if member.Role == model.RoleOwner && role != model.RoleOwner && owners == 1 {
+		return member, ErrLastOwner
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE organization_members SET role = $3 WHERE organization_id = $1 AND user_id = $2`, orgID, userID, role)
+	if err != nil {
+		return member, err
+	}
+
+	member.Role = role
+	return member, tx.Commit()
+}
+
+// RemoveMember removes a user from an organization. ErrLastOwner is returned rather than removing its only owner.
+func (c *PostgresSQL) RemoveMember(ctx context.Context, orgID string, userID string) error {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	owners, err := lockOrganization(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+
+	role := ""
+	err = tx.GetContext(ctx, &role,
+		`DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2 RETURNING role`, orgID, userID)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if role == model.RoleOwner && owners == 1 {
+		return ErrLastOwner
+	}
+
+	return tx.Commit()
+}
+
+// lockOrganization locks a live organization's row until tx ends, so changes to its members are serialised,
+// and returns how many owners it has
+func lockOrganization(ctx context.Context, tx *sqlx.Tx, orgID string) (int, error) {
+	locked := ""
+	err := tx.GetContext(ctx, &locked, `SELECT id FROM organizations WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, orgID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	owners := 0
+	err = tx.GetContext(ctx, &owners,
+		`SELECT COUNT(*) FROM organization_members WHERE organization_id = $1 AND role = $2`, orgID, model.RoleOwner)
+
+	return owners, err
+}
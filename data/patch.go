@@ -0,0 +1,41 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// patchResource updates only the given columns of a resource the user can access to their values in row, and
+// scans the whole updated row into dest. When version is set the update only applies if the stored version
+// matches, otherwise ErrVersionMismatch is returned. Resources being deleted can't be patched and return ErrDeleting.
+func (c *PostgresSQL) patchResource(ctx context.Context, tx *sqlx.Tx, table string, userID string, ID string, row interface{}, columns []string, version *int, dest interface{}) error {
+	args := map[string]interface{}{"id": ID, "user_id": userID, "version": version, "deleting": model.StatusDeleting}
+	names, values := []string{}, []string{}
+
+	for _, column := range columns {
+		value, ok := columnValue(row, column)
+		if _, taken := args[column]; !ok || taken {
+			return fmt.Errorf("unable to patch column %s of %s", column, table)
+		}
+
+		args[column] = value
+		names = append(names, pq.QuoteIdentifier(column))
+		values = append(values, ":"+column)
+	}
+
+	err := namedGet(ctx, tx, dest,
+		fmt.Sprintf(`UPDATE %s SET (%s, version, updated_at) = (%s, version + 1, now())
+		WHERE id = :id AND %s AND deleted_at IS NULL AND status <> :deleting
+		AND (CAST(:version AS INT) IS NULL OR version = :version)
+		RETURNING *`, table, strings.Join(names, ", "), strings.Join(values, ", "), accessibleBy(":user_id")), args)
+	if err == sql.ErrNoRows {
+		return c.versionError(ctx, table, userID, ID)
+	}
+	return err
+}
@@ -0,0 +1,27 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// namedGet runs a named query which returns a single row, such as an INSERT or UPDATE with RETURNING *, and
+// scans the row into dest. sql.ErrNoRows is returned if the query matched no rows.
+func namedGet(ctx context.Context, q sqlx.ExtContext, dest interface{}, query string, arg interface{}) error {
+	rows, err := sqlx.NamedQueryContext(ctx, q, query, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	return rows.StructScan(dest)
+}
@@ -0,0 +1,163 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+	"github.com/jmoiron/sqlx"
+)
+
+// enforceQuota returns ErrQuotaExceeded if adding a resource of the given capacity to usage would exceed quota.
+// When updating, previous is the capacity the resource had before. The count is only checked when creating and the
+// capacity only when it grows, so users over a lowered quota can still update and shrink what they have.
+func enforceQuota(quota model.Quota, usage model.QuotaUsage, creating bool, previous int, capacity int) error {
+	if creating && quota.MaxCount != nil && usage.Count+1 > *quota.MaxCount {
+		return fmt.Errorf("%w: %s count of %d would exceed the limit of %d",
+			ErrQuotaExceeded, quota.ResourceType, usage.Count+1, *quota.MaxCount)
+	}
+
+	if quota.MaxCapacity != nil && (creating || capacity > previous) && usage.Capacity+capacity > *quota.MaxCapacity {
+		return fmt.Errorf("%w: %s %s of %d would exceed the limit of %d",
+			ErrQuotaExceeded, quota.ResourceType, usage.CapacityUnit, usage.Capacity+capacity, *quota.MaxCapacity)
+	}
+
+	return nil
+}
+
+// defaultQuota returns the quota which applies to users without their own when no default quota has been set for a
+// resource type, which is the one the type declares. An unlimited quota is returned for unknown types.
+func defaultQuota(userID string, resourceType string) model.Quota {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return model.Quota{UserID: userID, ResourceType: resourceType}
+	}
+
+	maxCount, maxCapacity := t.DefaultQuota.MaxCount, t.DefaultQuota.MaxCapacity
+	return model.Quota{UserID: model.DefaultQuotaUser, ResourceType: resourceType, MaxCount: &maxCount, MaxCapacity: &maxCapacity}
+}
+
+// getQuota fetches the quota for a resource type which applies to the user, falling back to the default quota set
+// for all users and then to the one the type declares.
+func getQuota(ctx context.Context, q sqlx.QueryerContext, userID string, resourceType string) (model.Quota, error) {
+	quota := model.Quota{}
+
+	err := sqlx.GetContext(ctx, q, &quota,
+		`SELECT * FROM quotas WHERE resource_type = $1 AND user_id IN ($2, $3)
+		ORDER BY user_id = $3 LIMIT 1`,
+		resourceType, userID, model.DefaultQuotaUser)
+	if err == sql.ErrNoRows {
+		return defaultQuota(userID, resourceType), nil
+	}
+
+	return quota, err
+}
+
+// getUsage counts a user's live resources of a type and their total capacity, excluding the resource with id exclude.
+// Resources the user created in organizations count too, as organizations have no quota of their own.
+func getUsage(ctx context.Context, q sqlx.QueryerContext, userID string, resourceType string, exclude string) (model.QuotaUsage, error) {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return model.QuotaUsage{}, unknownType(resourceType)
+	}
+
+	usage := model.QuotaUsage{ResourceType: resourceType, CapacityUnit: t.CapacityUnit}
+
+	err := sqlx.GetContext(ctx, q, &usage, fmt.Sprintf(
+		`SELECT COUNT(*) AS count, COALESCE(SUM(%s), 0) AS capacity FROM %s
+		WHERE user_id = $1 AND id <> $2 AND deleted_at IS NULL`,
+		t.CapacityExpr(), t.Table),
+		userID, exclude)
+
+	return usage, err
+}
+
+// getCapacity fetches how much of the capacity quota a live resource uses, which is 0 if there is no such resource
+func getCapacity(ctx context.Context, q sqlx.QueryerContext, resourceType string, ID string) (int, error) {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return 0, unknownType(resourceType)
+	}
+
+	capacity := 0
+
+	err := sqlx.GetContext(ctx, q, &capacity, fmt.Sprintf(
+		`SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NULL`, t.CapacityExpr(), t.Table), ID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return capacity, err
+}
+
+// withQuota runs fn in a transaction once the user's quota for the resource type allows a resource of the
+// given capacity. Checks are serialised per user and resource type so concurrent requests cannot both pass.
+// replacing is the id of the resource being updated, or empty when creating.
+func (c *PostgresSQL) withQuota(ctx context.Context, userID string, resourceType string, replacing string, capacity int, fn func(tx *sqlx.Tx) error) error {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`, userID, resourceType)
+	if err != nil {
+		return err
+	}
+
+	quota, err := getQuota(ctx, tx, userID, resourceType)
+	if err != nil {
+		return err
+	}
+
+	usage, err := getUsage(ctx, tx, userID, resourceType, replacing)
+	if err != nil {
+		return err
+	}
+
+	previous := 0
+	if replacing != "" {
+		previous, err = getCapacity(ctx, tx, resourceType, replacing)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = enforceQuota(quota, usage, replacing == "", previous, capacity)
+	if err != nil {
+		c.logger.Info(newLog(ctx, "Rejected %s for user %s: %s", resourceType, userID, err.Error()))
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetQuotaUsage fetches a user's usage of every resource type against their quotas
+func (c *PostgresSQL) GetQuotaUsage(ctx context.Context, userID string) (model.QuotaUsages, error) {
+	usages := model.QuotaUsages{}
+
+	for _, t := range registry.Types() {
+		quota, err := getQuota(ctx, c.db, userID, t.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		usage, err := getUsage(ctx, c.db, userID, t.Name, "")
+		if err != nil {
+			return nil, err
+		}
+
+		usage.MaxCount = quota.MaxCount
+		usage.MaxCapacity = quota.MaxCapacity
+		usages = append(usages, usage)
+	}
+
+	return usages, nil
+}
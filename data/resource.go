@@ -1,15 +1,253 @@
 package data
 
-import "github.com/danielpadmore/cloudygo-service/model"
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
 
-// GetResources returns all resources available
-func (c *PostgresSQL) GetResources() (model.Resources, error) {
-	resources := model.Resources{}
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
 
-	err := c.db.Select(&resources, "SELECT * FROM resources WHERE available = TRUE")
+// writableColumns returns the columns of a resource type set by creates and updates
+func writableColumns(t *registry.Type) []string {
+	return append(append([]string{"name"}, t.Columns()...), "tags")
+}
+
+// rowArgs returns the values of the given columns of row as named query arguments
+func rowArgs(row interface{}, columns []string) map[string]interface{} {
+	args := map[string]interface{}{}
+	for _, column := range columns {
+		args[column], _ = columnValue(row, column)
+	}
+	return args
+}
+
+// rowValue returns the row a pointer returned by registry.Type.NewRow points to
+func rowValue(ptr interface{}) interface{} {
+	return reflect.ValueOf(ptr).Elem().Interface()
+}
+
+// namedList returns the named parameters of columns, for use in a query
+func namedList(columns []string) string {
+	params := []string{}
+	for _, column := range columns {
+		params = append(params, ":"+column)
+	}
+	return strings.Join(params, ", ")
+}
+
+// CreateResource creates a resource of a type from the name, fields and tags of row, which must be a row of that type.
+// The resource belongs to the organization row is in, if any. ErrAlreadyExists is returned if a live resource of the
+// same type and owner already has its name.
+func (c *PostgresSQL) CreateResource(ctx context.Context, userID string, resourceType string, row interface{}) (interface{}, error) {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return nil, unknownType(resourceType)
+	}
+
+	columns := writableColumns(t)
+	args := rowArgs(row, columns)
+	if tags, _ := args["tags"].(model.Tags); tags == nil {
+		args["tags"] = model.Tags{}
+	}
+	args["id"] = uuid.New().String()
+	args["user_id"] = userID
+	args["organization_id"], _ = columnValue(row, "organization_id")
+	args["status"] = model.StatusPending
+	columns = append([]string{"id", "user_id", "organization_id"}, append(columns, "status")...)
+
+	created := t.NewRow()
+
+	err := c.withQuota(ctx, userID, resourceType, "", t.RowCapacity(row), func(tx *sqlx.Tx) error {
+		return namedGet(ctx, tx, created, fmt.Sprintf(
+			`INSERT INTO %s (%s, created_at, updated_at)
+			VALUES (%s, now(), now())
+			RETURNING *`, t.Table, strings.Join(columns, ", "), namedList(columns)), args)
+	})
+	if err != nil {
+		return nil, nameError(err, resourceType, args["name"])
+	}
+
+	return rowValue(created), nil
+}
+
+// GetResource fetches a single resource of a type owned by the user or one of their organizations
+func (c *PostgresSQL) GetResource(ctx context.Context, userID string, resourceType string, ID string) (interface{}, error) {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return nil, unknownType(resourceType)
+	}
+
+	row := t.NewRow()
+
+	err := c.db.GetContext(ctx, row,
+		fmt.Sprintf(`SELECT * FROM %s WHERE id = $2 AND %s AND deleted_at IS NULL`, t.Table, accessibleBy("$1")),
+		userID, ID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return resources, nil
+	return rowValue(row), nil
+}
+
+// GetResources fetches a page of resources of a type owned by a user or their organizations, returning the cursor
+// of the next page if there is one
+func (c *PostgresSQL) GetResources(ctx context.Context, userID string, resourceType string, opts model.ListOptions) ([]interface{}, string, error) {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return nil, "", unknownType(resourceType)
+	}
+
+	query, args, err := listQuery(t.Table, rowValue(t.NewRow()), userID, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := c.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	resources := []interface{}{}
+	for rows.Next() {
+		row := t.NewRow()
+		if err := rows.StructScan(row); err != nil {
+			return nil, "", err
+		}
+		resources = append(resources, rowValue(row))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if opts.Limit > 0 && len(resources) > opts.Limit {
+		resources = resources[:opts.Limit]
+		next = encodeCursor(opts, resources[len(resources)-1])
+	}
+
+	return resources, next, nil
+}
+
+// UpdateResource replaces the name, fields and tags of an existing resource with those of row, keeping its tags
+// if row has none. When version is set the update only applies if the stored version matches, otherwise
+// ErrVersionMismatch is returned. Resources being deleted can't be updated and return ErrDeleting.
+func (c *PostgresSQL) UpdateResource(ctx context.Context, userID string, resourceType string, ID string, row interface{}, version *int) (interface{}, error) {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return nil, unknownType(resourceType)
+	}
+
+	creator, err := c.resourceCreator(ctx, t.Table, userID, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := writableColumns(t)
+	args := rowArgs(row, columns)
+	args["id"] = ID
+	args["user_id"] = userID
+	args["version"] = version
+	args["deleting"] = model.StatusDeleting
+
+	values := []string{}
+	for _, column := range columns {
+		if column == "tags" {
+			values = append(values, "COALESCE(:tags, tags)")
+			continue
+		}
+		values = append(values, ":"+column)
+	}
+
+	updated := t.NewRow()
+
+	err = c.withQuota(ctx, creator, resourceType, ID, t.RowCapacity(row), func(tx *sqlx.Tx) error {
+		err := namedGet(ctx, tx, updated,
+			fmt.Sprintf(`UPDATE %s SET (%s, version, updated_at) = (%s, version + 1, now())
+			WHERE id = :id AND %s AND deleted_at IS NULL AND status <> :deleting
+			AND (CAST(:version AS INT) IS NULL OR version = :version)
+			RETURNING *`, t.Table, strings.Join(columns, ", "), strings.Join(values, ", "), accessibleBy(":user_id")), args)
+		if err == sql.ErrNoRows {
+			return c.versionError(ctx, t.Table, userID, ID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nameError(err, resourceType, args["name"])
+	}
+
+	return rowValue(updated), nil
+}
+
+// PatchResource updates only the given columns of an existing resource to their values in row. When version is set
+// the update only applies if the stored version matches, otherwise ErrVersionMismatch is returned. Resources being
+// deleted can't be patched and return ErrDeleting.
+func (c *PostgresSQL) PatchResource(ctx context.Context, userID string, resourceType string, ID string, row interface{}, columns []string, version *int) (interface{}, error) {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return nil, unknownType(resourceType)
+	}
+
+	creator, err := c.resourceCreator(ctx, t.Table, userID, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	patched := t.NewRow()
+
+	err = c.withQuota(ctx, creator, resourceType, ID, t.RowCapacity(row), func(tx *sqlx.Tx) error {
+		return c.patchResource(ctx, tx, t.Table, userID, ID, row, columns, version, patched)
+	})
+	if err != nil {
+		name, _ := columnValue(row, "name")
+		return nil, nameError(err, resourceType, name)
+	}
+
+	return rowValue(patched), nil
+}
+
+// DeleteResource starts destroying an existing resource. When version is set the delete only applies if the
+// stored version matches, otherwise ErrVersionMismatch is returned. Deleting a resource which is already being
+// deleted does nothing, so retries don't postpone its deletion.
+func (c *PostgresSQL) DeleteResource(ctx context.Context, userID string, resourceType string, ID string, version *int) error {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return unknownType(resourceType)
+	}
+
+	res, err := c.db.NamedExecContext(ctx,
+		fmt.Sprintf(`UPDATE %s SET (status, version, updated_at) = (:status, version + 1, now())
+		WHERE id = :id AND %s AND deleted_at IS NULL AND status <> :status
+		AND (CAST(:version AS INT) IS NULL OR version = :version)`, t.Table, accessibleBy(":user_id")), map[string]interface{}{
+			"status":  model.StatusDeleting,
+			"id":      ID,
+			"user_id": userID,
+			"version": version,
+		})
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		err := c.versionError(ctx, t.Table, userID, ID)
+		if err == ErrDeleting {
+			return nil
+		}
+		return err
+	}
+
+	return nil
 }
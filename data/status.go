@@ -0,0 +1,85 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+// GetResourcesByStatus fetches resources of every type in a status which have not changed for at least the given age
+func (c *PostgresSQL) GetResourcesByStatus(ctx context.Context, status string, age time.Duration) ([]model.ResourceRef, error) {
+	refs := []model.ResourceRef{}
+
+	for _, t := range registry.Types() {
+		rows := []model.ResourceRef{}
+
+		err := c.db.SelectContext(ctx, &rows, fmt.Sprintf(
+			`SELECT $1::text AS type, id, status FROM %s
+			WHERE status = $2 AND updated_at <= now() - make_interval(secs => $3) AND deleted_at IS NULL`, t.Table),
+			t.Name, status, age.Seconds())
+		if err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, rows...)
+	}
+
+	return refs, nil
+}
+
+// TransitionResource moves a resource from its current status to another.
+// ErrNotFound is returned if the resource is no longer in the status held by ref.
+func (c *PostgresSQL) TransitionResource(ctx context.Context, ref model.ResourceRef, status string) error {
+	t, ok := registry.Lookup(ref.Type)
+	if !ok {
+		return unknownType(ref.Type)
+	}
+
+	deletedAt := "deleted_at"
+	if status == model.StatusDeleted {
+		deletedAt = "now()"
+	}
+
+	res, err := c.db.ExecContext(ctx, fmt.Sprintf(
+		`UPDATE %s SET (status, updated_at, deleted_at) = ($1, now(), %s)
+		WHERE id = $2 AND status = $3 AND deleted_at IS NULL`, t.Table, deletedAt),
+		status, ref.ID, ref.Status)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	c.logger.Debug(newLog(ctx, "Moved %s %s from %s to %s", ref.Type, ref.ID, ref.Status, status))
+	return nil
+}
+
+// CountResources counts the live resources of every type by status
+func (c *PostgresSQL) CountResources(ctx context.Context) ([]model.ResourceCount, error) {
+	counts := []model.ResourceCount{}
+
+	for _, t := range registry.Types() {
+		rows := []model.ResourceCount{}
+
+		err := c.db.SelectContext(ctx, &rows, fmt.Sprintf(
+			`SELECT $1::text AS type, status, COUNT(*) AS count FROM %s
+			WHERE deleted_at IS NULL GROUP BY status`, t.Table),
+			t.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, rows...)
+	}
+
+	return counts, nil
+}
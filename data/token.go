@@ -0,0 +1,111 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// CreateRefreshToken stores the hash of a new refresh token for a user, granting scopes and expiring after ttl
+func (c *PostgresSQL) CreateRefreshToken(ctx context.Context, userID string, tokenHash string, scopes []string, ttl time.Duration) error {
+	_, err := c.db.ExecContext(ctx,
+		`DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at <= now()`, userID)
+	if err != nil {
+		return err
+	}
+
+	return insertRefreshToken(ctx, c.db, userID, tokenHash, scopes, ttl)
+}
+
+func insertRefreshToken(ctx context.Context, e sqlx.ExecerContext, userID string, tokenHash string, scopes []string, ttl time.Duration) error {
+	_, err := e.ExecContext(ctx,
+		`INSERT INTO refresh_tokens (id, user_id, token_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, now(), now() + make_interval(secs => $5))`,
+		uuid.New().String(), userID, tokenHash, pq.StringArray(scopes), ttl.Seconds())
+	return err
+}
+
+// RotateRefreshToken revokes an active refresh token and replaces it with a new one for the same user and scopes,
+// so each refresh token can only be used once. ErrNotFound is returned if the token is unknown, expired or revoked.
+func (c *PostgresSQL) RotateRefreshToken(ctx context.Context, tokenHash string, newTokenHash string, ttl time.Duration) (model.RefreshToken, error) {
+	token := model.RefreshToken{}
+
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return token, err
+	}
+	defer tx.Rollback()
+
+	err = tx.GetContext(ctx, &token,
+		`UPDATE refresh_tokens SET revoked_at = now()
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > now()
+		RETURNING *`, tokenHash)
+	if err == sql.ErrNoRows {
+		return token, ErrNotFound
+	}
+	if err != nil {
+		return token, err
+	}
+
+	err = insertRefreshToken(ctx, tx, token.UserID, newTokenHash, token.Scopes, ttl)
+	if err != nil {
+		return token, err
+	}
+
+	err = tx.GetContext(ctx, &token, `SELECT * FROM refresh_tokens WHERE token_hash = $1`, newTokenHash)
+	if err != nil {
+		return token, err
+	}
+
+	return token, tx.Commit()
+}
+
+// RevokeRefreshToken revokes an active refresh token held by a user.
+// ErrNotFound is returned if the user holds no such token.
+func (c *PostgresSQL) RevokeRefreshToken(ctx context.Context, userID string, tokenHash string) error {
+	res, err := c.db.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = now()
+		WHERE user_id = $1 AND token_hash = $2 AND revoked_at IS NULL AND expires_at > now()`,
+		userID, tokenHash)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeToken adds an access token's ID to the revocation list until the token would have expired anyway
+func (c *PostgresSQL) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := c.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= now()`)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.db.ExecContext(ctx,
+		`INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING`, jti, expiresAt.UTC())
+	return err
+}
+
+// IsTokenRevoked checks the revocation list for an access token's ID
+func (c *PostgresSQL) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	revoked := false
+
+	err := c.db.GetContext(ctx, &revoked,
+		`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`, jti)
+
+	return revoked, err
+}
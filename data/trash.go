@@ -0,0 +1,86 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+	"github.com/jmoiron/sqlx"
+)
+
+// deletedResource is the part of a deleted resource needed to check it can be restored
+type deletedResource struct {
+	UserID   string `db:"user_id"`
+	Name     string `db:"name"`
+	Capacity int    `db:"capacity"`
+}
+
+// RestoreResource brings back a resource the user can access which was deleted within the retention period, so it
+// is provisioned again. The creator's quota is checked as if the resource were being created, and ErrAlreadyExists
+// is returned if a live resource of the same type and owner has since taken its name.
+func (c *PostgresSQL) RestoreResource(ctx context.Context, userID string, resourceType string, ID string, retention time.Duration) error {
+	t, ok := registry.Lookup(resourceType)
+	if !ok {
+		return unknownType(resourceType)
+	}
+
+	deleted := deletedResource{}
+
+	err := c.db.GetContext(ctx, &deleted, fmt.Sprintf(
+		`SELECT user_id, name, %s AS capacity FROM %s
+		WHERE id = $1 AND %s AND deleted_at > now() - make_interval(secs => $3)
+		AND (organization_id IS NULL OR organization_id IN (SELECT id FROM organizations WHERE deleted_at IS NULL))`,
+		t.CapacityExpr(), t.Table, accessibleBy("$2")),
+		ID, userID, retention.Seconds())
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.withQuota(ctx, deleted.UserID, resourceType, "", deleted.Capacity, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(
+			`UPDATE %s SET (status, version, updated_at, deleted_at) = ($1, version + 1, now(), NULL)
+			WHERE id = $2 AND deleted_at IS NOT NULL`, t.Table),
+			model.StatusPending, ID)
+		if err != nil {
+			return nameError(err, resourceType, deleted.Name)
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrNotFound
+		}
+
+		c.logger.Debug(newLog(ctx, "Restored %s %s", resourceType, ID))
+		return nil
+	})
+}
+
+// PurgeResources permanently removes resources of every type which were deleted longer ago than retention
+func (c *PostgresSQL) PurgeResources(ctx context.Context, retention time.Duration) ([]model.ResourceRef, error) {
+	refs := []model.ResourceRef{}
+
+	for _, t := range registry.Types() {
+		rows := []model.ResourceRef{}
+
+		err := c.db.SelectContext(ctx, &rows, fmt.Sprintf(
+			`DELETE FROM %s WHERE deleted_at <= now() - make_interval(secs => $2)
+			RETURNING $1::text AS type, id, status`, t.Table),
+			t.Name, retention.Seconds())
+		if err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, rows...)
+	}
+
+	return refs, nil
+}
@@ -1,20 +1,40 @@
 package data
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/danielpadmore/cloudygo-service/model"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// revocation is what updateUser revokes along with a change to a user
+type revocation int
+
+const (
+	// revokeNothing leaves the user's credentials alone
+	revokeNothing revocation = iota
+	// revokeSessions revokes every token issued to the user so far and their refresh tokens
+	revokeSessions
+	// revokeAll also revokes the user's API keys
+	revokeAll
+)
+
+// userColumns are the columns of users which are safe to return, leaving out the password hash
+const userColumns = `id, username, is_admin, disabled_at, tokens_revoked_at, password_reset_required, created_at, updated_at`
+
 // CreateUser creates a new user in the users table
-func (c *PostgresSQL) CreateUser(username string, password string) (model.User, error) {
-	c.logger.Verbose(newLog("Create user called"))
+func (c *PostgresSQL) CreateUser(ctx context.Context, username string, password string) (model.User, error) {
+	c.logger.Verbose(newLog(ctx, "Create user called"))
 	user := model.User{}
 
 	id := uuid.New()
 
-	rows, err := c.db.NamedQuery(
+	rows, err := c.db.NamedQueryContext(ctx,
 		`INSERT INTO users (id, username, password, created_at, updated_at) 
 		VALUES(:id, :username, crypt(:password, gen_salt('bf')), now(), now()) 
 		RETURNING id, username;`, map[string]interface{}{
@@ -23,7 +43,11 @@ func (c *PostgresSQL) CreateUser(username string, password string) (model.User,
 			"password": password,
 		})
 	if err != nil {
-		c.logger.Info(newLog("Error creating user: %s", err.Error()))
+		c.logger.Info(newLog(ctx, "Error creating user: %s", err.Error()))
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return user, fmt.Errorf("%w: username %s is taken", ErrAlreadyExists, username)
+		}
 		return user, err
 	}
 	defer rows.Close()
@@ -31,36 +55,158 @@ func (c *PostgresSQL) CreateUser(username string, password string) (model.User,
 	if rows.Next() {
 		err := rows.StructScan(&user)
 		if err != nil {
-			c.logger.Error(newLog("Error parsing user record: %s", err.Error()))
+			c.logger.Error(newLog(ctx, "Error parsing user record: %s", err.Error()))
 			return user, err
 		}
 	}
 
-	c.logger.Info(newLog("Created user %s", user.ID))
+	c.logger.Info(newLog(ctx, "Created user %s", user.ID))
 	return user, err
 }
 
 // AuthenticateUser ensures username and password match and returns result
-func (c *PostgresSQL) AuthenticateUser(username string, password string) (model.User, error) {
-	c.logger.Verbose(newLog("Authenticate user called"))
+func (c *PostgresSQL) AuthenticateUser(ctx context.Context, username string, password string) (model.User, error) {
+	c.logger.Verbose(newLog(ctx, "Authenticate user called"))
 	users := []model.User{}
 
-	err := c.db.Select(&users,
-		`SELECT id, username FROM users WHERE username = $1 AND password = crypt($2, password);`,
+	err := c.db.SelectContext(ctx, &users,
+		`SELECT `+userColumns+` FROM users WHERE username = $1 AND password = crypt($2, password) AND deleted_at IS NULL;`,
 		username,
 		password,
 	)
 
 	if err != nil {
-		c.logger.Info(newLog("Error authenticating user: %s", err.Error()))
+		c.logger.Info(newLog(ctx, "Error authenticating user: %s", err.Error()))
 		return model.User{}, err
 	}
 
 	if len(users) <= 0 {
-		c.logger.Info(newLog("User not found"))
+		c.logger.Info(newLog(ctx, "User not found"))
 		return model.User{}, errors.New("User not found")
 	}
 
-	c.logger.Info(newLog("User %s found", users[0].Username))
+	c.logger.Info(newLog(ctx, "User %s found", users[0].Username))
 	return users[0], nil
 }
+
+// GetUser fetches a single user by id
+func (c *PostgresSQL) GetUser(ctx context.Context, userID string) (model.User, error) {
+	user := model.User{}
+
+	err := c.db.GetContext(ctx, &user,
+		`SELECT `+userColumns+` FROM users WHERE id = $1 AND deleted_at IS NULL`, userID)
+	if err == sql.ErrNoRows {
+		return user, ErrNotFound
+	}
+
+	return user, err
+}
+
+// GetUserByUsername fetches a single user by username
+func (c *PostgresSQL) GetUserByUsername(ctx context.Context, username string) (model.User, error) {
+	user := model.User{}
+
+	err := c.db.GetContext(ctx, &user,
+		`SELECT `+userColumns+` FROM users WHERE username = $1 AND deleted_at IS NULL`, username)
+	if err == sql.ErrNoRows {
+		return user, ErrNotFound
+	}
+
+	return user, err
+}
+
+// GetUsers fetches a page of live users, returning the cursor of the next page if there is one
+func (c *PostgresSQL) GetUsers(ctx context.Context, opts model.ListOptions) (model.Users, string, error) {
+	users := model.Users{}
+
+	query, args, err := pageQuery("users", userColumns, model.User{}, []string{"deleted_at IS NULL"}, nil, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = c.db.SelectContext(ctx, &users, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if opts.Limit > 0 && len(users) > opts.Limit {
+		users = users[:opts.Limit]
+		next = encodeCursor(opts, users[len(users)-1])
+	}
+
+	return users, next, nil
+}
+
+// SetUserDisabled disables or re-enables a user. Disabling a user also revokes all of their tokens and API keys.
+func (c *PostgresSQL) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	if disabled {
+		return c.updateUser(ctx, userID, `disabled_at = now()`, revokeAll)
+	}
+	return c.updateUser(ctx, userID, `disabled_at = NULL`, revokeNothing)
+}
+
+// DeleteUser soft deletes a user and revokes all of their tokens and API keys
+func (c *PostgresSQL) DeleteUser(ctx context.Context, userID string) error {
+	return c.updateUser(ctx, userID, `deleted_at = now()`, revokeAll)
+}
+
+// ResetPassword replaces a user's password with a temporary one they must change,
+// revoking all of their tokens and API keys
+func (c *PostgresSQL) ResetPassword(ctx context.Context, userID string, password string) error {
+	return c.updateUser(ctx, userID, `password = crypt($2, gen_salt('bf')), password_reset_required = TRUE`, revokeAll, password)
+}
+
+// ChangePassword sets a user's password and clears any required reset. Every token issued to the user so far and all
+// of their refresh tokens are revoked, but their API keys are kept.
+func (c *PostgresSQL) ChangePassword(ctx context.Context, userID string, password string) error {
+	return c.updateUser(ctx, userID, `password = crypt($2, gen_salt('bf')), password_reset_required = FALSE`, revokeSessions, password)
+}
+
+// updateUser applies set to a live user, whose id is $1 and remaining args $2 onwards, and revokes their credentials
+// as far as revoke asks. ErrNotFound is returned if there is no such user.
+func (c *PostgresSQL) updateUser(ctx context.Context, userID string, set string, revoke revocation, args ...interface{}) error {
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if revoke >= revokeSessions {
+		// taken from the service's clock rather than the database's, as it is compared with the iat of tokens the
+		// service signs
+		set += fmt.Sprintf(`, tokens_revoked_at = $%d`, len(args)+2)
+		args = append(args, time.Now().UTC())
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE users SET `+set+`, updated_at = now() WHERE id = $1 AND deleted_at IS NULL`,
+		append([]interface{}{userID}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	if revoke >= revokeSessions {
+		_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if revoke >= revokeAll {
+		_, err = tx.ExecContext(ctx, `UPDATE api_keys SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
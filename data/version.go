@@ -0,0 +1,30 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+)
+
+// versionError explains why a conditional update or delete of a resource matched no rows, returning ErrDeleting
+// if the resource is being deleted, ErrVersionMismatch if it exists and ErrNotFound otherwise
+func (c *PostgresSQL) versionError(ctx context.Context, table string, userID string, id string) error {
+	status := ""
+
+	err := c.db.GetContext(ctx, &status, fmt.Sprintf(
+		`SELECT status FROM %s WHERE id = $1 AND %s AND deleted_at IS NULL`, table, accessibleBy("$2")),
+		id, userID)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if status == model.StatusDeleting {
+		return ErrDeleting
+	}
+	return ErrVersionMismatch
+}
@@ -18,7 +18,9 @@ require (
 	github.com/leodido/go-urn v1.2.1 // indirect
 	github.com/lib/pq v1.2.0
 	github.com/nicholasjackson/env v0.6.0
+	github.com/prometheus/client_golang v1.9.0
 	go.opentelemetry.io/otel v0.2.0
 	go.opentelemetry.io/otel/exporter/metric/prometheus v0.2.0
+	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad
 	gopkg.in/go-playground/validator.v9 v9.31.0
 )
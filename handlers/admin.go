@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/gorilla/mux"
+)
+
+// Admin contains handler data for managing users
+type Admin struct {
+	logger     logs.Logger
+	connection data.Connection
+}
+
+// userListFields are the user fields admin list requests can filter on
+var userListFields = map[string]fieldKind{
+	"username": stringField,
+}
+
+// adminUserResponse describes a user to an admin. Resources are only included when fetching a single user.
+type adminUserResponse struct {
+	ID                    string             `json:"id"`
+	Username              string             `json:"username"`
+	IsAdmin               bool               `json:"is_admin"`
+	DisabledAt            *string            `json:"disabled_at"`
+	PasswordResetRequired bool               `json:"password_reset_required"`
+	CreatedAt             time.Time          `json:"created_at"`
+	Resources             *model.QuotaUsages `json:"resources,omitempty"`
+}
+
+func newAdminUserResponse(u model.User) adminUserResponse {
+	var disabledAt *string
+	if u.DisabledAt.Valid {
+		disabledAt = &u.DisabledAt.String
+	}
+
+	return adminUserResponse{
+		ID:                    u.ID,
+		Username:              u.Username,
+		IsAdmin:               u.IsAdmin,
+		DisabledAt:            disabledAt,
+		PasswordResetRequired: u.PasswordResetRequired,
+		CreatedAt:             u.CreatedAt,
+	}
+}
+
+// resetPasswordResponse carries the temporary password given to a user whose password was reset
+type resetPasswordResponse struct {
+	TemporaryPassword string `json:"temporary_password"`
+}
+
+// NewAdmin creates a new Admin
+func NewAdmin(logger logs.Logger, connection data.Connection) *Admin {
+	return &Admin{logger, connection}
+}
+
+// writeJSON writes v as a JSON response
+func (a *Admin) writeJSON(v interface{}, rw http.ResponseWriter, r *http.Request) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		a.logger.Error(newLog(r.Context(), "Failed to parse user to JSON: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Failed to correctly parse user to JSON")
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(data)
+}
+
+// GetUsers handles listing users, optionally searching by username
+func (a *Admin) GetUsers(userID string, rw http.ResponseWriter, r *http.Request) {
+	a.logger.Info(newLog(r.Context(), "Get users request made at %s", r.URL.String()))
+
+	opts, err := parseListOptions(r, userListFields)
+	if err != nil {
+		a.logger.Info(newLog(r.Context(), "Invalid list request made: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, err.Error())
+		return
+	}
+
+	users, next, err := a.connection.GetUsers(r.Context(), opts)
+	if errors.Is(err, data.ErrInvalidListOptions) {
+		a.logger.Info(newLog(r.Context(), "Invalid list request made: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		a.logger.Warning(newLog(r.Context(), "Unable to find users: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find users")
+		return
+	}
+
+	res := []adminUserResponse{}
+	for _, u := range users {
+		res = append(res, newAdminUserResponse(u))
+	}
+
+	if next != "" {
+		rw.Header().Set(nextCursorHeader, next)
+	}
+	a.writeJSON(res, rw, r)
+}
+
+// GetUser handles fetching a user along with how many of each resource type they have created
+func (a *Admin) GetUser(userID string, rw http.ResponseWriter, r *http.Request) {
+	a.logger.Info(newLog(r.Context(), "Get user request made at %s", r.URL.String()))
+
+	ID := mux.Vars(r)["id"]
+
+	u, err := a.connection.GetUser(r.Context(), ID)
+	if errors.Is(err, data.ErrNotFound) {
+		a.logger.Info(newLog(r.Context(), "Unable to find user %s", ID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find user")
+		return
+	}
+	if err != nil {
+		a.logger.Warning(newLog(r.Context(), "Unable to find user %s: %s", ID, err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find user")
+		return
+	}
+
+	usage, err := a.connection.GetQuotaUsage(r.Context(), ID)
+	if err != nil {
+		a.logger.Warning(newLog(r.Context(), "Unable to count resources of user %s: %s", ID, err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to count user resources")
+		return
+	}
+
+	res := newAdminUserResponse(u)
+	res.Resources = &usage
+
+	a.writeJSON(res, rw, r)
+}
+
+// DisableUser handles disabling a user, which also signs them out everywhere and revokes their API keys
+func (a *Admin) DisableUser(userID string, rw http.ResponseWriter, r *http.Request) {
+	ok := a.applyToUser(userID, "disable", model.AuditDisable, func(ID string) error {
+		return a.connection.SetUserDisabled(r.Context(), ID, true)
+	}, rw, r)
+	if !ok {
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(rw, "%s", "user disabled")
+}
+
+// EnableUser handles re-enabling a disabled user
+func (a *Admin) EnableUser(userID string, rw http.ResponseWriter, r *http.Request) {
+	ok := a.applyToUser(userID, "enable", model.AuditEnable, func(ID string) error {
+		return a.connection.SetUserDisabled(r.Context(), ID, false)
+	}, rw, r)
+	if !ok {
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(rw, "%s", "user enabled")
+}
+
+// DeleteUser handles deleting a user, which also signs them out everywhere and revokes their API keys
+func (a *Admin) DeleteUser(userID string, rw http.ResponseWriter, r *http.Request) {
+	ok := a.applyToUser(userID, "delete", model.AuditDelete, func(ID string) error {
+		return a.connection.DeleteUser(r.Context(), ID)
+	}, rw, r)
+	if !ok {
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(rw, "%s", "user deleted")
+}
+
+// ResetPassword handles replacing a user's password with a temporary one, which is only returned in this response.
+// The user is signed out everywhere, their API keys are revoked, and they are asked to change the password when they sign in.
+func (a *Admin) ResetPassword(userID string, rw http.ResponseWriter, r *http.Request) {
+	password, err := auth.NewTemporaryPassword()
+	if err != nil {
+		a.logger.Error(newLog(r.Context(), "Unable to generate temporary password: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to generate temporary password")
+		return
+	}
+
+	ok := a.applyToUser(userID, "reset the password of", model.AuditResetPassword, func(ID string) error {
+		return a.connection.ResetPassword(r.Context(), ID, password)
+	}, rw, r)
+	if !ok {
+		return
+	}
+
+	a.writeJSON(resetPasswordResponse{password}, rw, r)
+}
+
+// applyToUser applies an admin action to the user in the request path, refusing to apply it to the admin themselves,
+// and records it in the audit log as auditAction. An error response is written and false returned if the action could
+// not be applied.
+func (a *Admin) applyToUser(userID string, action string, auditAction string, apply func(ID string) error, rw http.ResponseWriter, r *http.Request) bool {
+	a.logger.Info(newLog(r.Context(), "Request to %s user made at %s", action, r.URL.String()))
+
+	ID := mux.Vars(r)["id"]
+
+	if ID == userID {
+		a.logger.Info(newLog(r.Context(), "Admin %s tried to %s themselves", userID, action))
+		problem.Write(rw, r, problem.InvalidRequest, fmt.Sprintf("Unable to %s yourself", action))
+		return false
+	}
+
+	before, err := a.connection.GetUser(r.Context(), ID)
+	if err == nil {
+		err = apply(ID)
+	}
+	if errors.Is(err, data.ErrNotFound) {
+		a.logger.Info(newLog(r.Context(), "Unable to find user %s", ID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find user")
+		return false
+	}
+	if err != nil {
+		a.logger.Warning(newLog(r.Context(), "Unable to %s user %s: %s", action, ID, err.Error()))
+		problem.Write(rw, r, problem.Internal, fmt.Sprintf("Unable to %s user", action))
+		return false
+	}
+
+	a.logger.Info(newLog(r.Context(), "Admin %s was able to %s user %s", userID, action, ID))
+
+	var after interface{}
+	if u, err := a.connection.GetUser(r.Context(), ID); err == nil {
+		after = newAdminUserResponse(u)
+	}
+	recordAudit(a.logger, a.connection, r, userID, auditAction, "user", ID, newAdminUserResponse(before), after)
+
+	return true
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/danielpadmore/cloudygo-service/validation"
+	"github.com/gorilla/mux"
+)
+
+// APIKey contains handler data for a user's API keys
+type APIKey struct {
+	logger     logs.Logger
+	val        validation.Validator
+	connection data.Connection
+}
+
+type createAPIKeyRequestBody struct {
+	Name      string     `json:"name" validate:"required,min=1,max=200"`
+	Scopes    []string   `json:"scopes" validate:"dive,required,max=100"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// apiKeyResponse describes an API key. The key itself is only included when it is created.
+type apiKeyResponse struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Key        string    `json:"key,omitempty"`
+	Prefix     string    `json:"prefix"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  *string   `json:"expires_at"`
+	LastUsedAt *string   `json:"last_used_at"`
+}
+
+func newAPIKeyResponse(key model.APIKey) apiKeyResponse {
+	nullable := func(s sql.NullString) *string {
+		if !s.Valid {
+			return nil
+		}
+		return &s.String
+	}
+
+	scopes := []string(key.Scopes)
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return apiKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  nullable(key.ExpiresAt),
+		LastUsedAt: nullable(key.LastUsedAt),
+	}
+}
+
+// NewAPIKey creates a new APIKey
+func NewAPIKey(logger logs.Logger, val validation.Validator, connection data.Connection) *APIKey {
+	return &APIKey{logger, val, connection}
+}
+
+// GetAPIKeys handles listing the user's API keys
+func (a *APIKey) GetAPIKeys(userID string, rw http.ResponseWriter, r *http.Request) {
+	a.logger.Info(newLog(r.Context(), "Get API keys request made at %s", r.URL.String()))
+
+	keys, err := a.connection.GetAPIKeys(r.Context(), userID)
+	if err != nil {
+		a.logger.Warning(newLog(r.Context(), "Unable to find API keys: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find API keys")
+		return
+	}
+
+	res := []apiKeyResponse{}
+	for _, key := range keys {
+		res = append(res, newAPIKeyResponse(key))
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		a.logger.Error(newLog(r.Context(), "Failed to parse API keys to JSON: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Failed to correctly parse API keys to JSON")
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(data)
+}
+
+// CreateAPIKey handles creating a new API key. The key is only ever returned in this response.
+func (a *APIKey) CreateAPIKey(userID string, rw http.ResponseWriter, r *http.Request) {
+	a.logger.Info(newLog(r.Context(), "Create API key request made at %s", r.URL.String()))
+
+	input := createAPIKeyRequestBody{}
+
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		a.logger.Info(newLog(r.Context(), "Unable to parse request body: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, "Unable to parse request body")
+		return
+	}
+
+	if err := a.val.Validate.Struct(input); err != nil {
+		msg := a.val.ConcatReasons(err)
+		a.logger.Info(newLog(r.Context(), "Invalid create request made. Reasons: %s", msg))
+		problem.Write(rw, r, problem.ValidationFailed, msg, a.val.FieldErrors(err)...)
+		return
+	}
+
+	scopes := input.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{auth.FullAccess}
+	}
+	for _, scope := range scopes {
+		if err := auth.ValidateScope(scope); err != nil {
+			a.logger.Info(newLog(r.Context(), "Invalid create request made: %s", err.Error()))
+			problem.Write(rw, r, problem.InvalidRequest, err.Error())
+			return
+		}
+		if !auth.Allows(auth.ScopesFromContext(r.Context()), scope) {
+			a.logger.Info(newLog(r.Context(), "API key requested scope %s beyond the caller's own", scope))
+			problem.Write(rw, r, problem.Forbidden, fmt.Sprintf("Unable to grant scope %s as your credentials do not have it", scope))
+			return
+		}
+	}
+
+	expiresAt := sql.NullString{}
+	if input.ExpiresAt != nil {
+		if !input.ExpiresAt.After(time.Now()) {
+			a.logger.Info(newLog(r.Context(), "Invalid create request made. API key expiry is in the past"))
+			problem.Write(rw, r, problem.InvalidRequest, "expires_at must be in the future")
+			return
+		}
+		expiresAt = sql.NullString{String: input.ExpiresAt.UTC().Format(time.RFC3339Nano), Valid: true}
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		a.logger.Error(newLog(r.Context(), "Unable to generate API key: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to generate API key")
+		return
+	}
+
+	created, err := a.connection.CreateAPIKey(r.Context(), model.APIKey{
+		UserID:    userID,
+		Name:      input.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		a.logger.Warning(newLog(r.Context(), "Unable to create API key: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to create API key")
+		return
+	}
+
+	a.logger.Info(newLog(r.Context(), "Created API key %s", created.ID))
+	recordAudit(a.logger, a.connection, r, userID, model.AuditCreate, "api_key", created.ID, nil, newAPIKeyResponse(created))
+
+	res := newAPIKeyResponse(created)
+	res.Key = key
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		a.logger.Error(newLog(r.Context(), "Failed to parse API key to JSON: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Failed to correctly parse API key to JSON")
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusCreated)
+	rw.Write(data)
+}
+
+// DeleteAPIKey handles revoking one of the user's API keys
+func (a *APIKey) DeleteAPIKey(userID string, rw http.ResponseWriter, r *http.Request) {
+	a.logger.Info(newLog(r.Context(), "Delete API key request made at %s", r.URL.String()))
+
+	vars := mux.Vars(r)
+	ID := vars["id"]
+
+	err := a.connection.RevokeAPIKey(r.Context(), userID, ID)
+	if errors.Is(err, data.ErrNotFound) {
+		a.logger.Info(newLog(r.Context(), "Unable to find API key %s", ID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find API key")
+		return
+	}
+	if err != nil {
+		a.logger.Warning(newLog(r.Context(), "Unable to revoke API key: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to revoke API key")
+		return
+	}
+
+	a.logger.Info(newLog(r.Context(), "Revoked API key %s", ID))
+	recordAudit(a.logger, a.connection, r, userID, model.AuditDelete, "api_key", ID, nil, nil)
+
+	rw.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(rw, "%s", "API key revoked")
+}
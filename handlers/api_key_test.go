@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/gorilla/mux"
+)
+
+// serveAPIKeys calls an API key handler as testUser, authorized with the given scopes
+func serveAPIKeys(handler func(string, http.ResponseWriter, *http.Request), method string, ID string, body string, scopes ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/api-keys/"+ID, strings.NewReader(body))
+	r = r.WithContext(auth.ContextWithScopes(r.Context(), scopes))
+	if ID != "" {
+		r = mux.SetURLVars(r, map[string]string{"id": ID})
+	}
+
+	rw := httptest.NewRecorder()
+	handler(testUser, rw, r)
+	return rw
+}
+
+func TestCreateAPIKeyShownOnce(t *testing.T) {
+	db := data.NewMemory(newTestLogger())
+	h := NewAPIKey(newTestLogger(), newTestValidator(), db)
+
+	rw := serveAPIKeys(h.CreateAPIKey, http.MethodPost, "", `{"name": "ci"}`, auth.FullAccess)
+	if rw.Code != http.StatusCreated {
+		t.Fatalf("create returned %d: %s", rw.Code, rw.Body.String())
+	}
+
+	created := apiKeyResponse{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &created); err != nil {
+		t.Fatalf("create returned invalid JSON: %s", err)
+	}
+	if !auth.IsAPIKey(created.Key) || !strings.HasPrefix(created.Key, created.Prefix) {
+		t.Fatalf("created key %q with prefix %q, want a cgk_ key starting with its prefix", created.Key, created.Prefix)
+	}
+
+	stored, err := db.AuthenticateAPIKey(context.Background(), auth.HashToken(created.Key))
+	if err != nil {
+		t.Fatalf("created key does not authenticate: %s", err)
+	}
+	if stored.KeyHash == created.Key {
+		t.Errorf("key stored in plain text")
+	}
+
+	rw = serveAPIKeys(h.GetAPIKeys, http.MethodGet, "", "", auth.FullAccess)
+	if strings.Contains(rw.Body.String(), created.Key) || strings.Contains(rw.Body.String(), `"key"`) {
+		t.Errorf("list returned the key: %s", rw.Body.String())
+	}
+	if !strings.Contains(rw.Body.String(), created.Prefix) {
+		t.Errorf("list is missing the key prefix %s: %s", created.Prefix, rw.Body.String())
+	}
+}
+
+func TestCreateAPIKeyScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller []string
+		body   string
+		want   int
+	}{
+		{"defaults to full access", []string{auth.FullAccess}, `{"name": "ci"}`, http.StatusCreated},
+		{"scope the caller has", []string{"lambdas:write"}, `{"name": "ci", "scopes": ["lambdas:read"]}`, http.StatusCreated},
+		{"scope beyond the caller's", []string{"lambdas:read"}, `{"name": "ci", "scopes": ["lambdas:write"]}`, http.StatusForbidden},
+		{"full access beyond the caller's", []string{"lambdas:write"}, `{"name": "ci"}`, http.StatusForbidden},
+		{"malformed scope", []string{auth.FullAccess}, `{"name": "ci", "scopes": ["lambdas"]}`, http.StatusBadRequest},
+		{"expired", []string{auth.FullAccess}, `{"name": "ci", "expires_at": "2000-01-01T00:00:00Z"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewAPIKey(newTestLogger(), newTestValidator(), data.NewMemory(newTestLogger()))
+
+			rw := serveAPIKeys(h.CreateAPIKey, http.MethodPost, "", tt.body, tt.caller...)
+			if rw.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rw.Code, tt.want, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeleteAPIKey(t *testing.T) {
+	db := data.NewMemory(newTestLogger())
+	h := NewAPIKey(newTestLogger(), newTestValidator(), db)
+
+	rw := serveAPIKeys(h.CreateAPIKey, http.MethodPost, "", `{"name": "ci"}`, auth.FullAccess)
+	created := apiKeyResponse{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &created); err != nil {
+		t.Fatalf("create returned invalid JSON: %s", err)
+	}
+
+	rw = serveAPIKeys(h.DeleteAPIKey, http.MethodDelete, created.ID, "", auth.FullAccess)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("delete returned %d: %s", rw.Code, rw.Body.String())
+	}
+
+	rw = serveAPIKeys(h.DeleteAPIKey, http.MethodDelete, created.ID, "", auth.FullAccess)
+	checkProblem(t, rw, problem.NotFound)
+
+	rw = serveAPIKeys(h.GetAPIKeys, http.MethodGet, "", "", auth.FullAccess)
+	if strings.Contains(rw.Body.String(), created.ID) {
+		t.Errorf("list returned a revoked key: %s", rw.Body.String())
+	}
+}
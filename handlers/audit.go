@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+)
+
+// Audit contains handler data for querying the audit log
+type Audit struct {
+	logger     logs.Logger
+	connection data.Connection
+}
+
+// auditListFields are the audit event fields list requests can filter on
+var auditListFields = map[string]fieldKind{
+	"action":        stringField,
+	"resource_type": stringField,
+	"resource_id":   stringField,
+	"created_at":    timeField,
+}
+
+// NewAudit creates a new Audit
+func NewAudit(logger logs.Logger, connection data.Connection) *Audit {
+	return &Audit{logger, connection}
+}
+
+// GetAuditEvents handles fetching a page of the events the user caused
+func (a *Audit) GetAuditEvents(userID string, rw http.ResponseWriter, r *http.Request) {
+	a.logger.Info(newLog(r.Context(), "Get audit events request made at %s", r.URL.String()))
+
+	opts, err := parseListOptions(r, auditListFields)
+	if err != nil {
+		a.logger.Info(newLog(r.Context(), "Invalid list request made: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, err.Error())
+		return
+	}
+
+	events, next, err := a.connection.GetAuditEvents(r.Context(), userID, opts)
+	if errors.Is(err, data.ErrInvalidListOptions) {
+		a.logger.Info(newLog(r.Context(), "Invalid list request made: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		a.logger.Warning(newLog(r.Context(), "Unable to find audit events: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find audit events")
+		return
+	}
+
+	data, err := events.ToJSON()
+	if err != nil {
+		a.logger.Error(newLog(r.Context(), "Failed to parse audit events to JSON: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Failed to correctly parse audit events to JSON")
+		return
+	}
+
+	if next != "" {
+		rw.Header().Set(nextCursorHeader, next)
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(data)
+}
+
+// recordAudit appends an action the user took to the audit log, along with snapshots of the resource before and
+// after it, either of which may be nil. Failures are logged rather than failing the request, as the action has
+// already been taken.
+func recordAudit(logger logs.Logger, connection data.Connection, r *http.Request, userID string, action string, resourceType string, resourceID string, before interface{}, after interface{}) {
+	event := model.AuditEvent{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		SourceIP:     ClientIP(r),
+		RequestID:    RequestIDFromContext(r.Context()),
+	}
+
+	var err error
+	event.Before, err = json.Marshal(before)
+	if err == nil {
+		event.After, err = json.Marshal(after)
+	}
+	if err == nil {
+		err = connection.CreateAuditEvent(r.Context(), event)
+	}
+	if err != nil {
+		logger.Error(newLog(r.Context(), "Unable to record %s of %s %s in audit log: %s", action, resourceType, resourceID, err.Error()))
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/registry"
+)
+
+// getAuditEvents lists the audit events of userID through the handler
+func getAuditEvents(t *testing.T, h *Audit, userID string) model.AuditEvents {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodGet, "/audit", nil)
+	rw := httptest.NewRecorder()
+	h.GetAuditEvents(userID, rw, r)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("get audit events returned %d: %s", rw.Code, rw.Body.String())
+	}
+
+	events := model.AuditEvents{}
+	if err := events.FromJSON(rw.Body); err != nil {
+		t.Fatalf("get audit events returned invalid JSON: %s", err)
+	}
+	return events
+}
+
+func TestResourceChangesAudited(t *testing.T) {
+	db := data.NewMemory(newTestLogger())
+	h := newLambdaHandler(db)
+
+	created, _ := createLambda(t, h, `{"name": "audited-lambda", "concurrent_limit": 1}`)
+	ID := created["id"].(string)
+
+	rw := serve(h.UpdateResource, http.MethodPut, ID, `{"name": "audited-lambda", "concurrent_limit": 2}`, nil)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("update returned %d: %s", rw.Code, rw.Body.String())
+	}
+	rw = serve(h.DeleteResource, http.MethodDelete, ID, "", nil)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("delete returned %d: %s", rw.Code, rw.Body.String())
+	}
+
+	events := getAuditEvents(t, NewAudit(newTestLogger(), db), testUser)
+	want := []string{model.AuditCreate, model.AuditUpdate, model.AuditDelete}
+	if len(events) != len(want) {
+		t.Fatalf("got %d audit events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, event := range events {
+		if event.UserID != testUser || event.Action != want[i] || event.ResourceType != registry.Lambda.Name || event.ResourceID != ID {
+			t.Errorf("event %d = %s %s %s/%s, want %s %s %s/%s", i,
+				event.UserID, event.Action, event.ResourceType, event.ResourceID,
+				testUser, want[i], registry.Lambda.Name, ID)
+		}
+	}
+
+	if before := string(events[1].Before); before == "null" || before == "" {
+		t.Errorf("update has no before snapshot")
+	}
+	if after := string(events[2].After); after != "null" && after != "" {
+		t.Errorf("delete after snapshot = %s, want null", after)
+	}
+}
+
+func TestGetAuditEventsOnlyOwnEvents(t *testing.T) {
+	db := data.NewMemory(newTestLogger())
+	createLambda(t, newLambdaHandler(db), `{"name": "audited-lambda", "concurrent_limit": 1}`)
+
+	h := NewAudit(newTestLogger(), db)
+	if events := getAuditEvents(t, h, testUser); len(events) != 1 {
+		t.Errorf("got %d audit events for %s, want 1", len(events), testUser)
+	}
+	if events := getAuditEvents(t, h, "user-2"); len(events) != 0 {
+		t.Errorf("another user read %s's audit events: %+v", testUser, events)
+	}
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/danielpadmore/cloudygo-service/reaper"
+	"github.com/danielpadmore/cloudygo-service/registry"
+	"github.com/danielpadmore/cloudygo-service/validation"
+	"github.com/gorilla/mux"
+)
+
+// CloudResource contains handler data for the resources of a single registered type
+type CloudResource struct {
+	logger       logs.Logger
+	val          validation.Validator
+	connection   data.Connection
+	trash        func() reaper.Config
+	resourceType *registry.Type
+	listFields   map[string]fieldKind
+}
+
+// NewCloudResource creates a new CloudResource serving resources of resourceType
+func NewCloudResource(logger logs.Logger, val validation.Validator, connection data.Connection, trash func() reaper.Config, resourceType *registry.Type) *CloudResource {
+	listFields := map[string]fieldKind{
+		"name":            stringField,
+		"organization_id": stringField,
+		"tags":            tagsField,
+	}
+	for _, f := range resourceType.Fields {
+		if f.Secret {
+			continue
+		}
+		listFields[f.Name] = stringField
+		if f.Kind == registry.Int {
+			listFields[f.Name] = numberField
+		}
+	}
+
+	return &CloudResource{logger, val, connection, trash, resourceType, listFields}
+}
+
+// label returns how messages refer to a single resource, capitalized when it starts a sentence
+func (c *CloudResource) label(capitalized bool) string {
+	label := c.resourceType.Label
+	if capitalized {
+		return strings.ToUpper(label[:1]) + label[1:]
+	}
+	return label
+}
+
+// writeResource responds with a resource as JSON, along with its ETag
+func (c *CloudResource) writeResource(rw http.ResponseWriter, r *http.Request, row interface{}) {
+	data, err := json.Marshal(row)
+	if err != nil {
+		c.logger.Error(newLog(r.Context(), "Failed to parse %s to JSON: %s", c.label(false), err.Error()))
+		problem.Write(rw, r, problem.Internal, fmt.Sprintf("Failed to correctly parse %s to JSON", c.label(false)))
+		return
+	}
+
+	rw.Header().Set("ETag", etag(registry.Version(row), registry.Status(row)))
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(data)
+}
+
+// writeError responds with the problem matching an error returned changing a resource
+func (c *CloudResource) writeError(rw http.ResponseWriter, r *http.Request, action string, ID string, version *int, err error) {
+	switch {
+	case errors.Is(err, data.ErrNotFound):
+		c.logger.Info(newLog(r.Context(), "Unable to find %s %s", c.label(false), ID))
+		problem.Write(rw, r, problem.NotFound, fmt.Sprintf("Failed to find %s", c.label(false)))
+	case errors.Is(err, data.ErrVersionMismatch):
+		since := "it was read"
+		if version != nil {
+			since = "version " + strconv.Itoa(*version)
+		}
+		c.logger.Info(newLog(r.Context(), "%s %s has changed since %s", c.label(true), ID, since))
+		problem.Write(rw, r, problem.VersionMismatch, fmt.Sprintf("%s has been modified, fetch it again and retry", c.label(true)))
+	case errors.Is(err, data.ErrDeleting):
+		c.logger.Info(newLog(r.Context(), "Unable to %s %s %s which is being deleted", action, c.label(false), ID))
+		problem.Write(rw, r, problem.Conflict, fmt.Sprintf("%s is being deleted", c.label(true)))
+	case errors.Is(err, data.ErrAlreadyExists):
+		c.logger.Info(newLog(r.Context(), "Unable to %s %s: %s", action, c.label(false), err.Error()))
+		problem.Write(rw, r, problem.AlreadyExists, err.Error())
+	case errors.Is(err, data.ErrQuotaExceeded):
+		c.logger.Info(newLog(r.Context(), "Unable to %s %s: %s", action, c.label(false), err.Error()))
+		problem.Write(rw, r, problem.QuotaExceeded, err.Error())
+	default:
+		c.logger.Warning(newLog(r.Context(), "Unable to %s %s: %s", action, c.label(false), err.Error()))
+		problem.Write(rw, r, problem.Internal, fmt.Sprintf("Unable to %s %s", action, c.label(false)))
+	}
+}
+
+// decodeBody parses and validates a request body creating or replacing a resource, returning it as a row
+func (c *CloudResource) decodeBody(rw http.ResponseWriter, r *http.Request, action string) (interface{}, bool) {
+	input := c.resourceType.NewBody()
+
+	err := json.NewDecoder(r.Body).Decode(input)
+	if err != nil {
+		c.logger.Info(newLog(r.Context(), "Unable to parse request body: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, "Unable to parse request body")
+		return nil, false
+	}
+
+	if err := c.val.Validate.StructExcept(input, c.resourceType.Secrets()...); err != nil {
+		msg := c.val.ConcatReasons(err)
+		c.logger.Info(newLog(r.Context(), "Invalid %s request made. Reasons: %s", action, msg))
+		problem.Write(rw, r, problem.ValidationFailed, msg, c.val.FieldErrors(err)...)
+		return nil, false
+	}
+
+	return c.resourceType.RowFromBody(input), true
+}
+
+// GetResources handles fetching a page of resources
+func (c *CloudResource) GetResources(userID string, rw http.ResponseWriter, r *http.Request) {
+	c.logger.Info(newLog(r.Context(), "Get %ss request made at %s", c.label(false), r.URL.String()))
+
+	opts, err := parseResourceListOptions(r, c.listFields, c.trash)
+	if err != nil {
+		c.logger.Info(newLog(r.Context(), "Invalid list request made: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, err.Error())
+		return
+	}
+
+	resources, next, err := c.connection.GetResources(r.Context(), userID, c.resourceType.Name, opts)
+	if errors.Is(err, data.ErrInvalidListOptions) {
+		c.logger.Info(newLog(r.Context(), "Invalid list request made: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, err.Error())
+		return
+	}
+	if err != nil {
+		c.logger.Warning(newLog(r.Context(), "Unable to find %ss: %s", c.label(false), err.Error()))
+		problem.Write(rw, r, problem.Internal, fmt.Sprintf("Unable to find %ss", c.label(false)))
+		return
+	}
+
+	data, err := json.Marshal(resources)
+	if err != nil {
+		c.logger.Error(newLog(r.Context(), "Failed to parse %ss to JSON: %s", c.label(false), err.Error()))
+		problem.Write(rw, r, problem.Internal, fmt.Sprintf("Failed to correctly parse %ss to JSON", c.label(false)))
+		return
+	}
+
+	if next != "" {
+		rw.Header().Set(nextCursorHeader, next)
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(data)
+}
+
+// GetResource handles fetching a single resource
+func (c *CloudResource) GetResource(userID string, rw http.ResponseWriter, r *http.Request) {
+	c.logger.Info(newLog(r.Context(), "Get %s request made at %s", c.label(false), r.URL.String()))
+
+	ID := mux.Vars(r)["id"]
+
+	resource, err := c.connection.GetResource(r.Context(), userID, c.resourceType.Name, ID)
+	if err != nil {
+		c.writeError(rw, r, "find", ID, nil, err)
+		return
+	}
+
+	c.writeResource(rw, r, resource)
+}
+
+// CreateResource handles creating a new resource
+func (c *CloudResource) CreateResource(userID string, rw http.ResponseWriter, r *http.Request) {
+	c.logger.Info(newLog(r.Context(), "Create %s request made at %s", c.label(false), r.URL.String()))
+
+	body, ok := c.decodeBody(rw, r, "create")
+	if !ok {
+		return
+	}
+	body = registry.WithOrganization(body, requestOrganization(r))
+
+	created, err := c.connection.CreateResource(r.Context(), userID, c.resourceType.Name, body)
+	if err != nil {
+		c.writeError(rw, r, "create", "", nil, err)
+		return
+	}
+
+	recordAudit(c.logger, c.connection, r, userID, model.AuditCreate, c.resourceType.Name, registry.ID(created), nil, created)
+
+	c.writeResource(rw, r, created)
+}
+
+// UpdateResource handles replacing the name, fields and tags of an existing resource
+func (c *CloudResource) UpdateResource(userID string, rw http.ResponseWriter, r *http.Request) {
+	c.logger.Info(newLog(r.Context(), "Update %s request made at %s", c.label(false), r.URL.String()))
+
+	ID := mux.Vars(r)["id"]
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		c.logger.Info(newLog(r.Context(), "Invalid If-Match header: %s", err.Error()))
+		problem.Write(rw, r, problem.PreconditionFailed, err.Error())
+		return
+	}
+
+	body, ok := c.decodeBody(rw, r, "update")
+	if !ok {
+		return
+	}
+
+	before, err := c.connection.GetResource(r.Context(), userID, c.resourceType.Name, ID)
+	if err != nil {
+		c.writeError(rw, r, "update", ID, version, err)
+		return
+	}
+
+	updated, err := c.connection.UpdateResource(r.Context(), userID, c.resourceType.Name, ID, body, version)
+	if err != nil {
+		c.writeError(rw, r, "update", ID, version, err)
+		return
+	}
+
+	recordAudit(c.logger, c.connection, r, userID, model.AuditUpdate, c.resourceType.Name, ID, before, updated)
+
+	c.writeResource(rw, r, updated)
+}
+
+// PatchResource handles updating only the fields of an existing resource given in a JSON merge patch
+func (c *CloudResource) PatchResource(userID string, rw http.ResponseWriter, r *http.Request) {
+	c.logger.Info(newLog(r.Context(), "Patch %s request made at %s", c.label(false), r.URL.String()))
+
+	ID := mux.Vars(r)["id"]
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		c.logger.Info(newLog(r.Context(), "Invalid If-Match header: %s", err.Error()))
+		problem.Write(rw, r, problem.PreconditionFailed, err.Error())
+		return
+	}
+
+	if !isMergePatch(r) {
+		c.logger.Info(newLog(r.Context(), "Patch request made with Content-Type %s", r.Header.Get("Content-Type")))
+		problem.Write(rw, r, problem.UnsupportedMediaType, fmt.Sprintf("Content-Type must be %s", mergePatchContentType))
+		return
+	}
+
+	before, err := c.connection.GetResource(r.Context(), userID, c.resourceType.Name, ID)
+	if err != nil {
+		c.writeError(rw, r, "patch", ID, version, err)
+		return
+	}
+
+	current := c.resourceType.BodyFromRow(before)
+	input := c.resourceType.NewBody()
+
+	err = applyMergePatch(r, current, input)
+	if err != nil {
+		c.logger.Info(newLog(r.Context(), "Invalid patch request made: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, err.Error())
+		return
+	}
+	registry.DefaultTags(input)
+
+	if err := c.val.Validate.StructExcept(input, c.resourceType.Secrets()...); err != nil {
+		msg := c.val.ConcatReasons(err)
+		c.logger.Info(newLog(r.Context(), "Invalid patch request made. Reasons: %s", msg))
+		problem.Write(rw, r, problem.ValidationFailed, msg, c.val.FieldErrors(err)...)
+		return
+	}
+
+	changed, err := changedFields(current, input)
+	if err != nil {
+		c.logger.Error(newLog(r.Context(), "Unable to compare %s fields: %s", c.label(false), err.Error()))
+		problem.Write(rw, r, problem.Internal, fmt.Sprintf("Unable to patch %s", c.label(false)))
+		return
+	}
+
+	patched := before
+	if len(changed) > 0 {
+		body := c.resourceType.RowFromBody(input)
+		patched, err = c.connection.PatchResource(r.Context(), userID, c.resourceType.Name, ID, body, changed, version)
+	} else if version != nil && *version != registry.Version(before) {
+		err = data.ErrVersionMismatch
+	}
+	if err != nil {
+		c.writeError(rw, r, "patch", ID, version, err)
+		return
+	}
+
+	if len(changed) > 0 {
+		recordAudit(c.logger, c.connection, r, userID, model.AuditUpdate, c.resourceType.Name, ID, before, patched)
+	}
+
+	c.writeResource(rw, r, patched)
+}
+
+// DeleteResource handles deleting an existing resource. Deleting a resource which is already being deleted succeeds
+// without changing it.
+func (c *CloudResource) DeleteResource(userID string, rw http.ResponseWriter, r *http.Request) {
+	c.logger.Info(newLog(r.Context(), "Delete %s request made at %s", c.label(false), r.URL.String()))
+
+	ID := mux.Vars(r)["id"]
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		c.logger.Info(newLog(r.Context(), "Invalid If-Match header: %s", err.Error()))
+		problem.Write(rw, r, problem.PreconditionFailed, err.Error())
+		return
+	}
+
+	before, err := c.connection.GetResource(r.Context(), userID, c.resourceType.Name, ID)
+	if err != nil {
+		c.writeError(rw, r, "delete", ID, version, err)
+		return
+	}
+
+	if registry.Status(before) != model.StatusDeleting {
+		err = c.connection.DeleteResource(r.Context(), userID, c.resourceType.Name, ID, version)
+		if err != nil {
+			c.writeError(rw, r, "delete", ID, version, err)
+			return
+		}
+
+		recordAudit(c.logger, c.connection, r, userID, model.AuditDelete, c.resourceType.Name, ID, before, nil)
+	}
+
+	rw.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(rw, "%s deleted", c.label(true))
+}
+
+// RestoreResource handles restoring a resource which was deleted within the retention period
+func (c *CloudResource) RestoreResource(userID string, rw http.ResponseWriter, r *http.Request) {
+	c.logger.Info(newLog(r.Context(), "Restore %s request made at %s", c.label(false), r.URL.String()))
+
+	ID := mux.Vars(r)["id"]
+
+	err := c.connection.RestoreResource(r.Context(), userID, c.resourceType.Name, ID, c.trash().RetentionPeriod())
+	if errors.Is(err, data.ErrNotFound) {
+		c.logger.Info(newLog(r.Context(), "Unable to find deleted %s %s", c.label(false), ID))
+		problem.Write(rw, r, problem.NotFound, fmt.Sprintf("Failed to find deleted %s", c.label(false)))
+		return
+	}
+	if err != nil {
+		c.writeError(rw, r, "restore", ID, nil, err)
+		return
+	}
+
+	restored, err := c.connection.GetResource(r.Context(), userID, c.resourceType.Name, ID)
+	if err != nil {
+		c.logger.Warning(newLog(r.Context(), "Unable to find restored %s %s: %s", c.label(false), ID, err.Error()))
+		problem.Write(rw, r, problem.Internal, fmt.Sprintf("Unable to restore %s", c.label(false)))
+		return
+	}
+
+	recordAudit(c.logger, c.connection, r, userID, model.AuditRestore, c.resourceType.Name, ID, nil, restored)
+
+	c.writeResource(rw, r, restored)
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/danielpadmore/cloudygo-service/reaper"
+	"github.com/danielpadmore/cloudygo-service/registry"
+	"github.com/danielpadmore/cloudygo-service/validation"
+	"github.com/gorilla/mux"
+)
+
+// testUser is the id requests in these tests are authorized as
+const testUser = "user-1"
+
+func newTestLogger() logs.Logger {
+	return logs.NewStdLogger(logs.LogLevelFatal)
+}
+
+func newTestValidator() validation.Validator {
+	return validation.New(newTestLogger(), func() validation.PasswordPolicy { return validation.PasswordPolicy{} })
+}
+
+func newLambdaHandler(db data.Connection) *CloudResource {
+	return NewCloudResource(newTestLogger(), newTestValidator(), db, func() reaper.Config { return reaper.Config{} }, registry.Lambda)
+}
+
+// serve calls a handler authorized as testUser with a request for the resource with id ID
+func serve(handler func(string, http.ResponseWriter, *http.Request), method string, ID string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/lambdas/"+ID, strings.NewReader(body))
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	if ID != "" {
+		r = mux.SetURLVars(r, map[string]string{"id": ID})
+	}
+
+	rw := httptest.NewRecorder()
+	handler(testUser, rw, r)
+	return rw
+}
+
+// checkProblem fails the test unless the response is a problem+json body of type t
+func checkProblem(t *testing.T, rw *httptest.ResponseRecorder, want problem.Type) {
+	t.Helper()
+
+	if rw.Code != want.Status {
+		t.Errorf("status = %d, want %d", rw.Code, want.Status)
+	}
+	if ct := rw.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, problem.ContentType)
+	}
+
+	p := problem.Problem{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &p); err != nil {
+		t.Fatalf("body %q is not a problem: %s", rw.Body.String(), err)
+	}
+	if p.Code != want.Code || p.Status != want.Status {
+		t.Errorf("problem = %+v, want code %s and status %d", p, want.Code, want.Status)
+	}
+}
+
+// createLambda creates a lambda through the handler, returning its body and ETag
+func createLambda(t *testing.T, h *CloudResource, body string) (map[string]interface{}, string) {
+	t.Helper()
+
+	rw := serve(h.CreateResource, http.MethodPost, "", body, nil)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("create returned %d: %s", rw.Code, rw.Body.String())
+	}
+
+	created := map[string]interface{}{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &created); err != nil {
+		t.Fatalf("create returned invalid JSON: %s", err)
+	}
+	return created, rw.Header().Get("ETag")
+}
+
+func TestCreateResourceProblems(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want problem.Type
+	}{
+		{"malformed body", `{"name": `, problem.InvalidRequest},
+		{"invalid fields", `{"name": "abc", "concurrent_limit": 0}`, problem.ValidationFailed},
+		{"taken name", `{"name": "alpha-lambda", "concurrent_limit": 1}`, problem.AlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newLambdaHandler(data.NewMemory(newTestLogger()))
+			createLambda(t, h, `{"name": "alpha-lambda", "concurrent_limit": 1}`)
+
+			checkProblem(t, serve(h.CreateResource, http.MethodPost, "", tt.body, nil), tt.want)
+		})
+	}
+}
+
+func TestCreateResourceFieldErrors(t *testing.T) {
+	h := newLambdaHandler(data.NewMemory(newTestLogger()))
+
+	rw := serve(h.CreateResource, http.MethodPost, "", `{"name": "abc", "concurrent_limit": 500}`, nil)
+
+	p := problem.Problem{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &p); err != nil {
+		t.Fatalf("body is not a problem: %s", err)
+	}
+
+	fields := map[string]string{}
+	for _, e := range p.Errors {
+		fields[e.Field] = e.Rule
+	}
+	if fields["name"] != "min" || fields["concurrent_limit"] != "lte" {
+		t.Errorf("field errors = %+v, want name min and concurrent_limit lte", p.Errors)
+	}
+}
+
+func TestUpdateResourceIfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		ifMatch    func(current string) string
+		wantStatus int
+		wantType   *problem.Type
+	}{
+		{"no header", func(string) string { return "" }, http.StatusOK, nil},
+		{"any version", func(string) string { return "*" }, http.StatusOK, nil},
+		{"current version", func(current string) string { return current }, http.StatusOK, nil},
+		{"stale version", func(string) string { return `"1-pending"` }, http.StatusPreconditionFailed, &problem.VersionMismatch},
+		{"weak tag", func(string) string { return `W/"2"` }, http.StatusPreconditionFailed, &problem.PreconditionFailed},
+		{"not a version", func(string) string { return `"abc"` }, http.StatusPreconditionFailed, &problem.PreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newLambdaHandler(data.NewMemory(newTestLogger()))
+			created, _ := createLambda(t, h, `{"name": "alpha-lambda", "concurrent_limit": 1}`)
+			ID := created["id"].(string)
+
+			// bring the lambda to version 2 so version 1 is stale
+			rw := serve(h.UpdateResource, http.MethodPut, ID, `{"name": "alpha-lambda", "concurrent_limit": 2}`, nil)
+			current := rw.Header().Get("ETag")
+			if current != `"2-pending"` {
+				t.Fatalf("ETag after update = %s, want \"2-pending\"", current)
+			}
+
+			headers := map[string]string{}
+			if v := tt.ifMatch(current); v != "" {
+				headers["If-Match"] = v
+			}
+
+			rw = serve(h.UpdateResource, http.MethodPut, ID, `{"name": "alpha-lambda", "concurrent_limit": 3}`, headers)
+			if tt.wantType != nil {
+				checkProblem(t, rw, *tt.wantType)
+				return
+			}
+
+			if rw.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rw.Code, tt.wantStatus, rw.Body.String())
+			}
+			if etag := rw.Header().Get("ETag"); etag != `"3-pending"` {
+				t.Errorf("ETag = %s, want \"3-pending\"", etag)
+			}
+		})
+	}
+}
+
+func TestResourceETagAfterStatusTransition(t *testing.T) {
+	db := data.NewMemory(newTestLogger())
+	h := newLambdaHandler(db)
+	created, read := createLambda(t, h, `{"name": "alpha-lambda", "concurrent_limit": 1}`)
+	ID := created["id"].(string)
+
+	err := db.TransitionResource(context.Background(), model.ResourceRef{Type: registry.Lambda.Name, ID: ID, Status: model.StatusPending}, model.StatusProvisioning)
+	if err != nil {
+		t.Fatalf("TransitionResource: %s", err)
+	}
+
+	rw := serve(h.GetResource, http.MethodGet, ID, "", nil)
+	if etag := rw.Header().Get("ETag"); etag == read {
+		t.Errorf("ETag %s unchanged after the status changed", etag)
+	}
+
+	// the service changing the status doesn't conflict with the user's change
+	rw = serve(h.UpdateResource, http.MethodPut, ID, `{"name": "alpha-lambda", "concurrent_limit": 2}`, map[string]string{"If-Match": read})
+	if rw.Code != http.StatusOK {
+		t.Errorf("update with the ETag read before the status changed returned %d: %s", rw.Code, rw.Body.String())
+	}
+}
+
+func TestWriteErrorVersionMismatchWithoutVersion(t *testing.T) {
+	h := newLambdaHandler(data.NewMemory(newTestLogger()))
+
+	rw := httptest.NewRecorder()
+	h.writeError(rw, httptest.NewRequest(http.MethodGet, "/lambdas/abc", nil), "find", "abc", nil, data.ErrVersionMismatch)
+
+	checkProblem(t, rw, problem.VersionMismatch)
+}
+
+func TestChangeDeletingResource(t *testing.T) {
+	h := newLambdaHandler(data.NewMemory(newTestLogger()))
+	created, _ := createLambda(t, h, `{"name": "alpha-lambda", "concurrent_limit": 1}`)
+	ID := created["id"].(string)
+
+	rw := serve(h.DeleteResource, http.MethodDelete, ID, "", nil)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("delete returned %d: %s", rw.Code, rw.Body.String())
+	}
+
+	rw = serve(h.UpdateResource, http.MethodPut, ID, `{"name": "alpha-lambda", "concurrent_limit": 2}`, nil)
+	checkProblem(t, rw, problem.Conflict)
+
+	rw = serve(h.PatchResource, http.MethodPatch, ID, `{"concurrent_limit": 2}`, map[string]string{"Content-Type": mergePatchContentType})
+	checkProblem(t, rw, problem.Conflict)
+
+	rw = serve(h.DeleteResource, http.MethodDelete, ID, "", nil)
+	if rw.Code != http.StatusOK {
+		t.Errorf("repeated delete returned %d: %s", rw.Code, rw.Body.String())
+	}
+}
+
+func TestCreateResourceReturnsStoredRow(t *testing.T) {
+	h := newLambdaHandler(data.NewMemory(newTestLogger()))
+	created, _ := createLambda(t, h, `{"name": "alpha-lambda", "concurrent_limit": 1}`)
+
+	for _, field := range []string{"created_at", "updated_at"} {
+		value, _ := created[field].(string)
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			t.Errorf("%s = %q, want an RFC 3339 timestamp", field, value)
+		}
+	}
+	if created["created_at"] != created["updated_at"] {
+		t.Errorf("created_at = %v, updated_at = %v, want them equal", created["created_at"], created["updated_at"])
+	}
+	if created["status"] != "pending" {
+		t.Errorf("status = %v, want pending", created["status"])
+	}
+}
+
+func TestSQLDatabasePasswordNotBound(t *testing.T) {
+	db := data.NewMemory(newTestLogger())
+	h := NewCloudResource(newTestLogger(), newTestValidator(), db, func() reaper.Config { return reaper.Config{} }, registry.SQLDatabase)
+
+	rw := serve(h.CreateResource, http.MethodPost, "", `{"name": "orders-db", "username": "admin", "quantity": 1}`, nil)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("create without a password returned %d: %s", rw.Code, rw.Body.String())
+	}
+	created := map[string]interface{}{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &created); err != nil {
+		t.Fatalf("create returned invalid JSON: %s", err)
+	}
+	ID := created["id"].(string)
+
+	rw = serve(h.UpdateResource, http.MethodPut, ID, `{"name": "orders-db", "username": "admin", "password": "s3cret-password", "quantity": 2}`, nil)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("update returned %d: %s", rw.Code, rw.Body.String())
+	}
+	if strings.Contains(rw.Body.String(), "password") {
+		t.Errorf("update returned the password: %s", rw.Body.String())
+	}
+
+	stored, err := db.GetResource(context.Background(), testUser, registry.SQLDatabase.Name, ID)
+	if err != nil {
+		t.Fatalf("GetResource: %s", err)
+	}
+	if password := reflect.Indirect(reflect.ValueOf(stored)).FieldByName("Password").String(); password != "" {
+		t.Errorf("password %q was set from the request body", password)
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag formats a resource version and status as a strong entity tag. The status is included because the service
+// changes it while provisioning and deleting resources without changing their version.
+func etag(version int, status string) string {
+	return fmt.Sprintf(`"%d-%s"`, version, status)
+}
+
+// ifMatchVersion reads the version a request requires from its If-Match header, ignoring the status part of the
+// entity tag so changes the service made to the status don't conflict with the user's change.
+// nil is returned when the header is missing or "*", meaning any version may be changed.
+func ifMatchVersion(r *http.Request) (*int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 2 {
+		return nil, errors.New("If-Match must be a single strong entity tag")
+	}
+
+	version, err := strconv.Atoi(strings.SplitN(strings.Trim(header, `"`), "-", 2)[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s does not match any version", header)
+	}
+
+	return &version, nil
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    *int
+		wantErr bool
+	}{
+		{header: ""},
+		{header: "*"},
+		{header: " * "},
+		{header: `"3"`, want: intPtr(3)},
+		{header: ` "12" `, want: intPtr(12)},
+		{header: `"3-running"`, want: intPtr(3)},
+		{header: `3`, wantErr: true},
+		{header: `"`, wantErr: true},
+		{header: `W/"3"`, wantErr: true},
+		{header: `"3", "4"`, wantErr: true},
+		{header: `"abc"`, wantErr: true},
+		{header: `"-running"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/lambdas/1", nil)
+			r.Header.Set("If-Match", tt.header)
+
+			got, err := ifMatchVersion(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("version = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	if got := etag(7, "running"); got != `"7-running"` {
+		t.Errorf("etag(7, running) = %s, want \"7-running\"", got)
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/lambdas/1", nil)
+	r.Header.Set("If-Match", etag(7, "running"))
+	if version, err := ifMatchVersion(r); err != nil || *version != 7 {
+		t.Errorf("ifMatchVersion(etag(7, running)) = %v, %v, want 7", version, err)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -19,11 +19,11 @@ func NewHealth(logger logs.Logger, db data.Connection) *Health {
 }
 
 func (h *Health) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	_, err := h.db.IsConnected()
+	_, err := h.db.IsConnected(r.Context())
 	if err != nil {
 		rw.WriteHeader(http.StatusInternalServerError)
-		h.logger.Error(newLog("Error with database connection: %s", err.Error()))
+		h.logger.Error(newLog(r.Context(), "Error with database connection: %s", err.Error()))
 	}
 
-	h.logger.Error(newLog("Health check ok"))
+	h.logger.Error(newLog(r.Context(), "Health check ok"))
 }
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+)
+
+// idempotencyKeyHeader is the request header clients set to make a create request safe to retry
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// defaultIdempotencyTTL is used when no TTL is configured
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// replayedHeaders are the response headers stored and replayed alongside the body
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// Idempotency contains handler data for replaying create requests
+type Idempotency struct {
+	logger     logs.Logger
+	connection data.Connection
+	ttl        time.Duration
+}
+
+// NewIdempotency creates a new Idempotency which remembers responses for ttl
+func NewIdempotency(logger logs.Logger, connection data.Connection, ttl time.Duration) *Idempotency {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	return &Idempotency{logger, connection, ttl}
+}
+
+// Handle wraps a create handler so a retry sent with the same Idempotency-Key header replays the original
+// response instead of creating a duplicate. Reusing a key with a different request is rejected.
+func (i *Idempotency) Handle(next func(userID string, rw http.ResponseWriter, r *http.Request)) func(userID string, rw http.ResponseWriter, r *http.Request) {
+	return func(userID string, rw http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(userID, rw, r)
+			return
+		}
+
+		if len(key) > 255 {
+			problem.Write(rw, r, problem.InvalidRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			i.logger.Info(newLog(r.Context(), "Unable to read request body: %s", err.Error()))
+			problem.Write(rw, r, problem.InvalidRequest, "Unable to read request body")
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		hash := requestHash(r, body)
+
+		err = i.connection.CreateIdempotencyKey(r.Context(), userID, key, hash, i.ttl)
+		if errors.Is(err, data.ErrAlreadyExists) {
+			i.replay(userID, key, hash, rw, r)
+			return
+		}
+		if err != nil {
+			i.logger.Warning(newLog(r.Context(), "Unable to store idempotency key: %s", err.Error()))
+			problem.Write(rw, r, problem.Internal, "Unable to store idempotency key")
+			return
+		}
+
+		rec := newResponseRecorder(rw)
+		next(userID, rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			err := i.connection.DeleteIdempotencyKey(r.Context(), userID, key)
+			if err != nil {
+				i.logger.Warning(newLog(r.Context(), "Unable to release idempotency key: %s", err.Error()))
+			}
+			return
+		}
+
+		headers := map[string]string{}
+		for _, h := range replayedHeaders {
+			if v := rec.Header().Get(h); v != "" {
+				headers[h] = v
+			}
+		}
+		encoded, _ := json.Marshal(headers)
+
+		err = i.connection.CompleteIdempotencyKey(r.Context(), model.IdempotencyRecord{
+			UserID:     userID,
+			Key:        key,
+			StatusCode: rec.status,
+			Headers:    string(encoded),
+			Body:       rec.body.Bytes(),
+		})
+		if err != nil {
+			i.logger.Warning(newLog(r.Context(), "Unable to store response for idempotency key: %s", err.Error()))
+		}
+	}
+}
+
+// replay writes the stored response for a key which is already in use
+func (i *Idempotency) replay(userID string, key string, hash string, rw http.ResponseWriter, r *http.Request) {
+	record, err := i.connection.GetIdempotencyKey(r.Context(), userID, key)
+	if errors.Is(err, data.ErrNotFound) {
+		problem.Write(rw, r, problem.Conflict, "Idempotency-Key expired while in use, retry the request")
+		return
+	}
+	if err != nil {
+		i.logger.Warning(newLog(r.Context(), "Unable to find idempotency key: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find idempotency key")
+		return
+	}
+
+	if record.RequestHash != hash {
+		i.logger.Info(newLog(r.Context(), "Idempotency key reused with a different request by user %s", userID))
+		problem.Write(rw, r, problem.IdempotencyKeyReused, "Idempotency-Key has already been used with a different request")
+		return
+	}
+
+	if record.StatusCode == 0 {
+		problem.Write(rw, r, problem.Conflict, "A request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	headers := map[string]string{}
+	_ = json.Unmarshal([]byte(record.Headers), &headers)
+	for h, v := range headers {
+		rw.Header().Set(h, v)
+	}
+
+	i.logger.Info(newLog(r.Context(), "Replaying response for idempotency key of user %s", userID))
+	rw.Header().Set("Idempotent-Replayed", "true")
+	rw.WriteHeader(record.StatusCode)
+	rw.Write(record.Body)
+}
+
+// requestHash identifies a request by its method, path and body
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/problem"
+)
+
+// idempotentRequest is a create request sent through the idempotency handler
+type idempotentRequest struct {
+	key  string
+	path string
+	body string
+}
+
+func (req idempotentRequest) send(handler func(string, http.ResponseWriter, *http.Request)) *httptest.ResponseRecorder {
+	path := req.path
+	if path == "" {
+		path = "/lambdas"
+	}
+
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(req.body))
+	if req.key != "" {
+		r.Header.Set(idempotencyKeyHeader, req.key)
+	}
+
+	rw := httptest.NewRecorder()
+	handler(testUser, rw, r)
+	return rw
+}
+
+func TestIdempotencyHandle(t *testing.T) {
+	first := idempotentRequest{key: "key-1", body: `{"name": "alpha"}`}
+
+	tests := []struct {
+		name         string
+		status       int
+		retry        idempotentRequest
+		wantCalls    int
+		wantStatus   int
+		wantReplayed bool
+		wantType     *problem.Type
+	}{
+		{
+			name:         "retry replays the response",
+			status:       http.StatusCreated,
+			retry:        first,
+			wantCalls:    1,
+			wantStatus:   http.StatusCreated,
+			wantReplayed: true,
+		},
+		{
+			name:         "client errors are replayed",
+			status:       http.StatusBadRequest,
+			retry:        first,
+			wantCalls:    1,
+			wantStatus:   http.StatusBadRequest,
+			wantReplayed: true,
+		},
+		{
+			name:       "server errors release the key",
+			status:     http.StatusInternalServerError,
+			retry:      first,
+			wantCalls:  2,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "another key runs again",
+			status:     http.StatusCreated,
+			retry:      idempotentRequest{key: "key-2", body: first.body},
+			wantCalls:  2,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "no key runs again",
+			status:     http.StatusCreated,
+			retry:      idempotentRequest{body: first.body},
+			wantCalls:  2,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:      "key reused with another body",
+			status:    http.StatusCreated,
+			retry:     idempotentRequest{key: first.key, body: `{"name": "bravo"}`},
+			wantCalls: 1,
+			wantType:  &problem.IdempotencyKeyReused,
+		},
+		{
+			name:      "key reused on another path",
+			status:    http.StatusCreated,
+			retry:     idempotentRequest{key: first.key, path: "/virtual-machines", body: first.body},
+			wantCalls: 1,
+			wantType:  &problem.IdempotencyKeyReused,
+		},
+		{
+			name:      "key too long",
+			status:    http.StatusCreated,
+			retry:     idempotentRequest{key: strings.Repeat("k", 256), body: first.body},
+			wantCalls: 1,
+			wantType:  &problem.InvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := NewIdempotency(newTestLogger(), data.NewMemory(newTestLogger()), time.Hour).Handle(
+				func(userID string, rw http.ResponseWriter, r *http.Request) {
+					calls++
+					body, _ := ioutil.ReadAll(r.Body)
+					rw.Header().Set("Content-Type", "application/json")
+					rw.Header().Set("ETag", etag(calls, "pending"))
+					rw.WriteHeader(tt.status)
+					fmt.Fprintf(rw, `{"call": %d, "body": %s}`, calls, body)
+				})
+
+			original := first.send(handler)
+			retried := tt.retry.send(handler)
+
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantType != nil {
+				checkProblem(t, retried, *tt.wantType)
+				return
+			}
+
+			if retried.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", retried.Code, tt.wantStatus)
+			}
+			if replayed := retried.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.wantReplayed {
+				t.Errorf("replayed = %t, want %t", replayed, tt.wantReplayed)
+			}
+			if !tt.wantReplayed {
+				return
+			}
+
+			if retried.Body.String() != original.Body.String() {
+				t.Errorf("replayed body = %s, want %s", retried.Body.String(), original.Body.String())
+			}
+			for _, h := range replayedHeaders {
+				if retried.Header().Get(h) != original.Header().Get(h) {
+					t.Errorf("replayed %s = %q, want %q", h, retried.Header().Get(h), original.Header().Get(h))
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyHandleInProgress(t *testing.T) {
+	db := data.NewMemory(newTestLogger())
+	idempotency := NewIdempotency(newTestLogger(), db, time.Hour)
+
+	calls := 0
+	var retried *httptest.ResponseRecorder
+	var handler func(string, http.ResponseWriter, *http.Request)
+	handler = idempotency.Handle(func(userID string, rw http.ResponseWriter, r *http.Request) {
+		calls++
+		// retry while the first request is still being handled
+		if calls == 1 {
+			retried = idempotentRequest{key: "key-1", body: `{}`}.send(handler)
+		}
+		rw.WriteHeader(http.StatusCreated)
+	})
+
+	idempotentRequest{key: "key-1", body: `{}`}.send(handler)
+
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+	checkProblem(t, retried, problem.Conflict)
+}
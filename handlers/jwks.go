@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/problem"
+)
+
+// JWKS contains handler data for publishing token verification keys
+type JWKS struct {
+	logger logs.Logger
+	keys   *auth.KeySet
+}
+
+// NewJWKS creates a new JWKS
+func NewJWKS(logger logs.Logger, keys *auth.KeySet) *JWKS {
+	return &JWKS{logger, keys}
+}
+
+// ServeHTTP handles fetching the public keys which verify tokens
+func (j *JWKS) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	j.logger.Debug(newLog(r.Context(), "JWKS request made at %s", r.URL.String()))
+
+	set := j.keys.JWKS()
+
+	data, err := set.ToJSON()
+	if err != nil {
+		j.logger.Error(newLog(r.Context(), "Failed to parse key set to JSON: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Failed to correctly parse key set to JSON")
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(data)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/model"
+)
+
+// maxListLimit caps the page size a client can request
+const maxListLimit = 1000
+
+// nextCursorHeader carries the cursor of the next page on list responses
+const nextCursorHeader = "X-Next-Cursor"
+
+// fieldKind describes how a filterable field is compared
+type fieldKind int
+
+const (
+	stringField fieldKind = iota
+	numberField
+	timeField
+	tagsField
+)
+
+// sortFields are the fields every list endpoint can be sorted by
+var sortFields = map[string]bool{"name": true, "created_at": true}
+
+// parseListOptions reads limit, cursor, sort and field filters from the query string.
+// Filters take the form field=value, field_prefix=value for strings, or field_gte/field_lte=number.
+// Lists of tagged rows can be filtered with tag=key:value, which may be repeated to require several tags.
+// Times are given in RFC 3339 format.
+// Requests acting on behalf of an organization only list its resources.
+func parseListOptions(r *http.Request, fields map[string]fieldKind) (model.ListOptions, error) {
+	return parseListQuery(r, r.URL.Query(), fields)
+}
+
+// parseListQuery reads list options from query like parseListOptions, for callers which handle some parameters themselves
+func parseListQuery(r *http.Request, query url.Values, fields map[string]fieldKind) (model.ListOptions, error) {
+	opts := model.ListOptions{}
+
+	for key, values := range query {
+		value := values[len(values)-1]
+
+		switch key {
+		case "limit":
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxListLimit {
+				return opts, fmt.Errorf("limit must be a number between 1 and %d", maxListLimit)
+			}
+			opts.Limit = limit
+			continue
+		case "cursor":
+			opts.Cursor = value
+			continue
+		case "tag":
+			if fields["tags"] != tagsField {
+				return opts, fmt.Errorf("unknown query parameter %s", key)
+			}
+			for _, value := range values {
+				filter, err := parseTagFilter(value)
+				if err != nil {
+					return opts, err
+				}
+				opts.Filters = append(opts.Filters, filter)
+			}
+			continue
+		case "sort":
+			opts.Sort = strings.TrimPrefix(value, "-")
+			opts.Descending = strings.HasPrefix(value, "-")
+			if !sortFields[opts.Sort] {
+				return opts, fmt.Errorf("unable to sort by %s", opts.Sort)
+			}
+			continue
+		}
+
+		filter, err := parseFilter(key, value, fields)
+		if err != nil {
+			return opts, err
+		}
+		opts.Filters = append(opts.Filters, filter)
+	}
+
+	if orgID := auth.OrganizationFromContext(r.Context()); orgID != "" {
+		if _, ok := fields["organization_id"]; ok {
+			opts.Filters = append(opts.Filters, model.Filter{Field: "organization_id", Operator: model.FilterEquals, Value: orgID})
+		}
+	}
+
+	return opts, nil
+}
+
+func parseFilter(key string, value string, fields map[string]fieldKind) (model.Filter, error) {
+	filter := model.Filter{Field: key, Operator: model.FilterEquals}
+
+	for _, op := range []string{model.FilterPrefix, model.FilterGreaterOrEqual, model.FilterLessOrEqual} {
+		if strings.HasSuffix(key, "_"+op) {
+			filter.Field = strings.TrimSuffix(key, "_"+op)
+			filter.Operator = op
+			break
+		}
+	}
+
+	kind, ok := fields[filter.Field]
+	if !ok || kind == tagsField {
+		return filter, fmt.Errorf("unknown query parameter %s", key)
+	}
+
+	if kind == stringField {
+		if filter.Operator != model.FilterEquals && filter.Operator != model.FilterPrefix {
+			return filter, fmt.Errorf("%s can only be filtered by equality or prefix", filter.Field)
+		}
+		filter.Value = value
+		return filter, nil
+	}
+
+	if filter.Operator == model.FilterPrefix {
+		return filter, fmt.Errorf("%s can not be filtered by prefix", filter.Field)
+	}
+
+	if kind == timeField {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 time", key)
+		}
+		filter.Value = t.UTC()
+		return filter, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return filter, fmt.Errorf("%s must be a number", key)
+	}
+	filter.Value = n
+
+	return filter, nil
+}
+
+// parseTagFilter reads a tag filter of the form key:value
+func parseTagFilter(value string) (model.Filter, error) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return model.Filter{}, fmt.Errorf("tag must be given as key:value")
+	}
+
+	return model.Filter{Field: "tags", Operator: model.FilterContains, Value: model.Tags{parts[0]: parts[1]}}, nil
+}
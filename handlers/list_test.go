@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/model"
+)
+
+var testListFields = map[string]fieldKind{
+	"name":             stringField,
+	"concurrent_limit": numberField,
+	"created_at":       timeField,
+	"tags":             tagsField,
+	"organization_id":  stringField,
+}
+
+// sortFilters orders filters so options can be compared regardless of query parameter order
+func sortFilters(filters []model.Filter) {
+	sort.Slice(filters, func(i, j int) bool {
+		if filters[i].Field != filters[j].Field {
+			return filters[i].Field < filters[j].Field
+		}
+		return filters[i].Operator < filters[j].Operator
+	})
+}
+
+func TestParseListOptions(t *testing.T) {
+	created := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    model.ListOptions
+		wantErr bool
+	}{
+		{name: "empty", query: ""},
+		{name: "limit and cursor", query: "limit=25&cursor=abc", want: model.ListOptions{Limit: 25, Cursor: "abc"}},
+		{name: "last value wins", query: "limit=5&limit=10", want: model.ListOptions{Limit: 10}},
+		{name: "zero limit", query: "limit=0", wantErr: true},
+		{name: "limit over maximum", query: "limit=1001", wantErr: true},
+		{name: "limit not a number", query: "limit=ten", wantErr: true},
+		{name: "sort", query: "sort=name", want: model.ListOptions{Sort: "name"}},
+		{name: "descending sort", query: "sort=-created_at", want: model.ListOptions{Sort: "created_at", Descending: true}},
+		{name: "unknown sort", query: "sort=concurrent_limit", wantErr: true},
+		{
+			name:  "string equality",
+			query: "name=alpha",
+			want:  model.ListOptions{Filters: []model.Filter{{Field: "name", Operator: model.FilterEquals, Value: "alpha"}}},
+		},
+		{
+			name:  "string prefix",
+			query: "name_prefix=al",
+			want:  model.ListOptions{Filters: []model.Filter{{Field: "name", Operator: model.FilterPrefix, Value: "al"}}},
+		},
+		{name: "string range", query: "name_gte=a", wantErr: true},
+		{
+			name:  "number range",
+			query: "concurrent_limit_gte=2&concurrent_limit_lte=8",
+			want: model.ListOptions{Filters: []model.Filter{
+				{Field: "concurrent_limit", Operator: model.FilterGreaterOrEqual, Value: int64(2)},
+				{Field: "concurrent_limit", Operator: model.FilterLessOrEqual, Value: int64(8)},
+			}},
+		},
+		{name: "number prefix", query: "concurrent_limit_prefix=1", wantErr: true},
+		{name: "number not a number", query: "concurrent_limit=many", wantErr: true},
+		{
+			name:  "time range",
+			query: "created_at_gte=2021-03-04T07:06:07%2B02:00",
+			want:  model.ListOptions{Filters: []model.Filter{{Field: "created_at", Operator: model.FilterGreaterOrEqual, Value: created}}},
+		},
+		{name: "time not RFC 3339", query: "created_at_gte=yesterday", wantErr: true},
+		{
+			name:  "repeated tags",
+			query: "tag=env:dev&tag=team:core",
+			want: model.ListOptions{Filters: []model.Filter{
+				{Field: "tags", Operator: model.FilterContains, Value: model.Tags{"env": "dev"}},
+				{Field: "tags", Operator: model.FilterContains, Value: model.Tags{"team": "core"}},
+			}},
+		},
+		{
+			name:  "tag value with colon",
+			query: "tag=url:http://x",
+			want:  model.ListOptions{Filters: []model.Filter{{Field: "tags", Operator: model.FilterContains, Value: model.Tags{"url": "http://x"}}}},
+		},
+		{name: "tag without value", query: "tag=env", wantErr: true},
+		{name: "tag without key", query: "tag=:dev", wantErr: true},
+		{name: "tags as a field", query: "tags=env", wantErr: true},
+		{name: "unknown field", query: "colour=red", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/lambdas?"+tt.query, nil)
+
+			got, err := parseListOptions(r, testListFields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			sortFilters(got.Filters)
+			sortFilters(tt.want.Filters)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("options = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseListOptionsTagsNotListable(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/audit?tag=env:dev", nil)
+
+	_, err := parseListOptions(r, map[string]fieldKind{"action": stringField})
+	if err == nil {
+		t.Errorf("tag filter accepted for a list without tags")
+	}
+}
+
+func TestParseListOptionsOrganization(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/lambdas", nil)
+	r = r.WithContext(auth.ContextWithOrganization(r.Context(), "org-1"))
+
+	got, err := parseListOptions(r, testListFields)
+	if err != nil {
+		t.Fatalf("parseListOptions: %s", err)
+	}
+
+	want := []model.Filter{{Field: "organization_id", Operator: model.FilterEquals, Value: "org-1"}}
+	if !reflect.DeepEqual(got.Filters, want) {
+		t.Errorf("filters = %+v, want %+v", got.Filters, want)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/danielpadmore/cloudygo-service/problem"
+)
+
+// usernameLoginKey identifies the failed sign ins counted against a username
+func usernameLoginKey(username string) string {
+	return "username:" + username
+}
+
+// ipLoginKey identifies the failed sign ins counted against the address a request came from
+func ipLoginKey(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// ClientIP returns the address a request came from, without its port
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// checkLockout refuses a sign in while its username or address is locked out after repeated failures.
+// An error response is written and false returned if the sign in must not go ahead.
+func (user *User) checkLockout(username string, rw http.ResponseWriter, r *http.Request) bool {
+	lockout, err := user.connection.GetLoginLockout(r.Context(), []string{usernameLoginKey(username), ipLoginKey(r)})
+	if err != nil {
+		user.logger.Error(newLog(r.Context(), "Unable to check sign in lockout of user %s: %s", username, err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to sign in")
+		return false
+	}
+
+	if lockout > 0 {
+		user.logger.Info(newLog(r.Context(), "Refused sign in of user %s from %s which is locked out for %s", username, ipLoginKey(r), lockout))
+		rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockout.Seconds()))))
+		problem.Write(rw, r, problem.LockedOut, "Too many failed sign in attempts, try again later")
+		return false
+	}
+
+	return true
+}
+
+// recordLoginFailure counts a failed sign in against the username and address, locking out further sign ins
+// once either has failed too many times. Errors are logged rather than failing the request.
+func (user *User) recordLoginFailure(username string, r *http.Request) {
+	conf := user.lockout()
+	limits := map[string]int{
+		usernameLoginKey(username): conf.MaxUsernameFailures,
+		ipLoginKey(r):              conf.MaxIPFailures,
+	}
+
+	for key, maxFailures := range limits {
+		if maxFailures <= 0 {
+			continue
+		}
+
+		failures, err := user.connection.RecordLoginFailure(r.Context(), key, conf.LockoutWindow())
+		if err != nil {
+			user.logger.Error(newLog(r.Context(), "Unable to record failed sign in for %s: %s", key, err.Error()))
+			continue
+		}
+
+		lockout := conf.LockoutFor(failures, maxFailures)
+		if lockout == 0 {
+			continue
+		}
+
+		user.logger.Warning(newLog(r.Context(), "Locking out sign ins for %s for %s after %d failures", key, lockout, failures))
+		err = user.connection.LockLogin(r.Context(), key, lockout)
+		if err != nil {
+			user.logger.Error(newLog(r.Context(), "Unable to lock out sign ins for %s: %s", key, err.Error()))
+		}
+	}
+}
+
+// clearLoginFailures forgets the failed sign ins of a username once the user has signed in.
+// Failures from the address are left to expire, as other users may share it.
+func (user *User) clearLoginFailures(username string, r *http.Request) {
+	err := user.connection.ClearLoginFailures(r.Context(), usernameLoginKey(username))
+	if err != nil {
+		user.logger.Error(newLog(r.Context(), "Unable to clear failed sign ins of user %s: %s", username, err.Error()))
+	}
+}
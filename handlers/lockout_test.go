@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/config"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/problem"
+)
+
+// signInAttempt is a sign in made from an address
+type signInAttempt struct {
+	addr     string
+	username string
+	password string
+}
+
+func TestSignInLockout(t *testing.T) {
+	lockout := auth.LockoutConfig{
+		MaxUsernameFailures: 3,
+		MaxIPFailures:       5,
+		Lockout:             config.Duration{Duration: time.Minute},
+	}
+
+	wrong := func(addr string, username string, n int) []signInAttempt {
+		attempts := []signInAttempt{}
+		for i := 0; i < n; i++ {
+			attempts = append(attempts, signInAttempt{addr, username, "wrong-password"})
+		}
+		return attempts
+	}
+
+	tests := []struct {
+		name       string
+		conf       auth.LockoutConfig
+		before     []signInAttempt
+		attempt    signInAttempt
+		wantStatus int
+	}{
+		{
+			name:       "under the username limit",
+			conf:       lockout,
+			before:     wrong("10.0.0.1:1", "alice", 2),
+			attempt:    signInAttempt{"10.0.0.1:1", "alice", testPassword},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "username locked out",
+			conf:       lockout,
+			before:     wrong("10.0.0.1:1", "alice", 3),
+			attempt:    signInAttempt{"10.0.0.1:1", "alice", testPassword},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "username locked out from every address",
+			conf:       lockout,
+			before:     wrong("10.0.0.1:1", "alice", 3),
+			attempt:    signInAttempt{"10.0.0.2:1", "alice", testPassword},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "other usernames unaffected",
+			conf:       lockout,
+			before:     wrong("10.0.0.1:1", "alice", 3),
+			attempt:    signInAttempt{"10.0.0.2:1", "bob", testPassword},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "address locked out",
+			conf:       lockout,
+			before:     append(wrong("10.0.0.1:1", "alice", 2), wrong("10.0.0.1:2", "bob", 2)...),
+			attempt:    signInAttempt{"10.0.0.1:3", "carol", "wrong-password"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "address locked out after its limit",
+			conf:       lockout,
+			before:     append(append(wrong("10.0.0.1:1", "alice", 2), wrong("10.0.0.1:2", "bob", 2)...), wrong("10.0.0.1:3", "carol", 1)...),
+			attempt:    signInAttempt{"10.0.0.1:4", "bob", testPassword},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "success clears username failures",
+			conf:       lockout,
+			before:     append(append(wrong("10.0.0.1:1", "alice", 2), signInAttempt{"10.0.0.2:1", "alice", testPassword}), wrong("10.0.0.3:1", "alice", 2)...),
+			attempt:    signInAttempt{"10.0.0.4:1", "alice", testPassword},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "lockout disabled",
+			conf:       auth.LockoutConfig{},
+			before:     wrong("10.0.0.1:1", "alice", 20),
+			attempt:    signInAttempt{"10.0.0.1:1", "alice", testPassword},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newUserHandler(t, data.NewMemory(newTestLogger()), tt.conf)
+
+			for _, a := range tt.before {
+				signIn(user, a.addr, a.username, a.password)
+			}
+
+			rw := signIn(user, tt.attempt.addr, tt.attempt.username, tt.attempt.password)
+			if tt.wantStatus == http.StatusTooManyRequests {
+				checkProblem(t, rw, problem.LockedOut)
+
+				retryAfter, err := strconv.Atoi(rw.Header().Get("Retry-After"))
+				if err != nil || retryAfter < 1 || retryAfter > 60 {
+					t.Errorf("Retry-After = %q, want between 1 and 60 seconds", rw.Header().Get("Retry-After"))
+				}
+				return
+			}
+
+			if rw.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rw.Code, tt.wantStatus, rw.Body.String())
+			}
+		})
+	}
+}
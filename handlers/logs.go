@@ -1,11 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/danielpadmore/cloudygo-service/logs"
 )
 
-func newLog(message string, a ...interface{}) logs.LogStruct {
-	return logs.NewLog("ROUTER", fmt.Sprintf(message, a...))
+func newLog(ctx context.Context, message string, a ...interface{}) logs.LogStruct {
+	return logs.NewLog("ROUTER", fmt.Sprintf(message, a...)).WithContext(ctx)
 }
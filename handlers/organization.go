@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/danielpadmore/cloudygo-service/validation"
+	"github.com/gorilla/mux"
+)
+
+// Organization contains handler data for organizations and their members
+type Organization struct {
+	logger     logs.Logger
+	val        validation.Validator
+	connection data.Connection
+}
+
+type createOrganizationRequestBody struct {
+	Name string `json:"name" validate:"required,min=1,max=200"`
+}
+
+type addMemberRequestBody struct {
+	Username string `json:"username" validate:"required"`
+	Role     string `json:"role" validate:"required,oneof=owner admin member"`
+}
+
+type updateMemberRequestBody struct {
+	Role string `json:"role" validate:"required,oneof=owner admin member"`
+}
+
+// organizationResponse describes an organization along with its members
+type organizationResponse struct {
+	model.Organization
+	Members model.Memberships `json:"members"`
+}
+
+// requestOrganization returns the organization a request acts on behalf of, or nil for the user's own resources
+func requestOrganization(r *http.Request) *string {
+	orgID := auth.OrganizationFromContext(r.Context())
+	if orgID == "" {
+		return nil
+	}
+	return &orgID
+}
+
+// NewOrganization creates a new Organization
+func NewOrganization(logger logs.Logger, val validation.Validator, connection data.Connection) *Organization {
+	return &Organization{logger, val, connection}
+}
+
+// membership fetches the user's membership of the organization in the request path, writing an error response and
+// returning false if they don't belong to it or their role is less than min
+func (o *Organization) membership(userID string, min string, rw http.ResponseWriter, r *http.Request) (model.Membership, bool) {
+	orgID := mux.Vars(r)["id"]
+
+	member, err := o.connection.GetMembership(r.Context(), orgID, userID)
+	if errors.Is(err, data.ErrNotFound) {
+		o.logger.Info(newLog(r.Context(), "Unable to find organization %s", orgID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find organization")
+		return member, false
+	}
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to find membership of organization %s: %s", orgID, err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find organization")
+		return member, false
+	}
+
+	if !model.RoleAtLeast(member.Role, min) {
+		o.logger.Info(newLog(r.Context(), "User %s has the %s role in organization %s, which is below %s", userID, member.Role, orgID, min))
+		problem.Write(rw, r, problem.Forbidden, fmt.Sprintf("Requires the %s role in the organization", min))
+		return member, false
+	}
+
+	return member, true
+}
+
+// writeJSON writes v as a JSON response with the given status
+func (o *Organization) writeJSON(status int, v interface{}, rw http.ResponseWriter, r *http.Request) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		o.logger.Error(newLog(r.Context(), "Failed to parse organization to JSON: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Failed to correctly parse organization to JSON")
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	rw.Write(data)
+}
+
+// GetOrganizations handles listing the organizations the user belongs to
+func (o *Organization) GetOrganizations(userID string, rw http.ResponseWriter, r *http.Request) {
+	o.logger.Info(newLog(r.Context(), "Get organizations request made at %s", r.URL.String()))
+
+	orgs, err := o.connection.GetOrganizations(r.Context(), userID)
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to find organizations: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find organizations")
+		return
+	}
+
+	o.writeJSON(http.StatusOK, orgs, rw, r)
+}
+
+// GetOrganization handles fetching an organization the user belongs to, along with its members
+func (o *Organization) GetOrganization(userID string, rw http.ResponseWriter, r *http.Request) {
+	o.logger.Info(newLog(r.Context(), "Get organization request made at %s", r.URL.String()))
+
+	ID := mux.Vars(r)["id"]
+
+	org, err := o.connection.GetOrganization(r.Context(), userID, ID)
+	if errors.Is(err, data.ErrNotFound) {
+		o.logger.Info(newLog(r.Context(), "Unable to find organization %s", ID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find organization")
+		return
+	}
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to find organization %s: %s", ID, err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find organization")
+		return
+	}
+
+	members, err := o.connection.GetMembers(r.Context(), ID)
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to find members of organization %s: %s", ID, err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find organization members")
+		return
+	}
+
+	o.writeJSON(http.StatusOK, organizationResponse{org, members}, rw, r)
+}
+
+// CreateOrganization handles creating a new organization owned by the user
+func (o *Organization) CreateOrganization(userID string, rw http.ResponseWriter, r *http.Request) {
+	o.logger.Info(newLog(r.Context(), "Create organization request made at %s", r.URL.String()))
+
+	input := createOrganizationRequestBody{}
+
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		o.logger.Info(newLog(r.Context(), "Unable to parse request body: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, "Unable to parse request body")
+		return
+	}
+
+	if err := o.val.Validate.Struct(input); err != nil {
+		msg := o.val.ConcatReasons(err)
+		o.logger.Info(newLog(r.Context(), "Invalid create request made. Reasons: %s", msg))
+		problem.Write(rw, r, problem.ValidationFailed, msg, o.val.FieldErrors(err)...)
+		return
+	}
+
+	org, err := o.connection.CreateOrganization(r.Context(), userID, input.Name)
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to create organization: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to create organization")
+		return
+	}
+
+	o.logger.Info(newLog(r.Context(), "Created organization %s", org.ID))
+	recordAudit(o.logger, o.connection, r, userID, model.AuditCreate, "organization", org.ID, nil, org)
+
+	o.writeJSON(http.StatusCreated, org, rw, r)
+}
+
+// DeleteOrganization handles deleting an organization, which only its owners can do once it owns no resources
+func (o *Organization) DeleteOrganization(userID string, rw http.ResponseWriter, r *http.Request) {
+	o.logger.Info(newLog(r.Context(), "Delete organization request made at %s", r.URL.String()))
+
+	if _, ok := o.membership(userID, model.RoleOwner, rw, r); !ok {
+		return
+	}
+
+	ID := mux.Vars(r)["id"]
+
+	before, err := o.connection.GetOrganization(r.Context(), userID, ID)
+	if errors.Is(err, data.ErrNotFound) {
+		o.logger.Info(newLog(r.Context(), "Unable to find organization %s", ID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find organization")
+		return
+	}
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to delete organization: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to delete organization")
+		return
+	}
+
+	err = o.connection.DeleteOrganization(r.Context(), ID)
+	if errors.Is(err, data.ErrNotFound) {
+		o.logger.Info(newLog(r.Context(), "Unable to find organization %s", ID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find organization")
+		return
+	}
+	if errors.Is(err, data.ErrNotEmpty) {
+		o.logger.Info(newLog(r.Context(), "Organization %s still owns resources", ID))
+		problem.Write(rw, r, problem.Conflict, "Organization still owns resources, delete them first")
+		return
+	}
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to delete organization: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to delete organization")
+		return
+	}
+
+	o.logger.Info(newLog(r.Context(), "Deleted organization %s", ID))
+	recordAudit(o.logger, o.connection, r, userID, model.AuditDelete, "organization", ID, before, nil)
+
+	rw.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(rw, "%s", "organization deleted")
+}
+
+// AddMember handles adding a user to an organization. Admins can add members and admins, and owners can also add owners.
+func (o *Organization) AddMember(userID string, rw http.ResponseWriter, r *http.Request) {
+	o.logger.Info(newLog(r.Context(), "Add organization member request made at %s", r.URL.String()))
+
+	caller, ok := o.membership(userID, model.RoleAdmin, rw, r)
+	if !ok {
+		return
+	}
+
+	input := addMemberRequestBody{}
+
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		o.logger.Info(newLog(r.Context(), "Unable to parse request body: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, "Unable to parse request body")
+		return
+	}
+
+	if err := o.val.Validate.Struct(input); err != nil {
+		msg := o.val.ConcatReasons(err)
+		o.logger.Info(newLog(r.Context(), "Invalid add member request made. Reasons: %s", msg))
+		problem.Write(rw, r, problem.ValidationFailed, msg, o.val.FieldErrors(err)...)
+		return
+	}
+
+	if !model.RoleAtLeast(caller.Role, input.Role) {
+		o.logger.Info(newLog(r.Context(), "A member with the %s role can not grant the %s role", caller.Role, input.Role))
+		problem.Write(rw, r, problem.Forbidden, fmt.Sprintf("Requires the %s role in the organization to grant it", input.Role))
+		return
+	}
+
+	user, err := o.connection.GetUserByUsername(r.Context(), input.Username)
+	if errors.Is(err, data.ErrNotFound) {
+		o.logger.Info(newLog(r.Context(), "Unable to find user %s", input.Username))
+		problem.Write(rw, r, problem.NotFound, "Failed to find user")
+		return
+	}
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to find user %s: %s", input.Username, err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find user")
+		return
+	}
+
+	member, err := o.connection.AddMember(r.Context(), model.Membership{
+		OrganizationID: caller.OrganizationID,
+		UserID:         user.ID,
+		Username:       user.Username,
+		Role:           input.Role,
+	})
+	if errors.Is(err, data.ErrAlreadyExists) {
+		o.logger.Info(newLog(r.Context(), "User %s already belongs to organization %s", user.ID, caller.OrganizationID))
+		problem.Write(rw, r, problem.AlreadyExists, "User is already a member of the organization")
+		return
+	}
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to add organization member: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to add organization member")
+		return
+	}
+
+	o.logger.Info(newLog(r.Context(), "Added user %s to organization %s as %s", user.ID, caller.OrganizationID, input.Role))
+	recordAudit(o.logger, o.connection, r, userID, model.AuditCreate, "organization_member", memberAuditID(member), nil, member)
+
+	o.writeJSON(http.StatusCreated, member, rw, r)
+}
+
+// UpdateMember handles changing a member's role. Nobody can change the role of a member more privileged than themselves,
+// or grant a role more privileged than their own.
+func (o *Organization) UpdateMember(userID string, rw http.ResponseWriter, r *http.Request) {
+	o.logger.Info(newLog(r.Context(), "Update organization member request made at %s", r.URL.String()))
+
+	caller, ok := o.membership(userID, model.RoleAdmin, rw, r)
+	if !ok {
+		return
+	}
+
+	memberID := mux.Vars(r)["user_id"]
+
+	input := updateMemberRequestBody{}
+
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		o.logger.Info(newLog(r.Context(), "Unable to parse request body: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, "Unable to parse request body")
+		return
+	}
+
+	if err := o.val.Validate.Struct(input); err != nil {
+		msg := o.val.ConcatReasons(err)
+		o.logger.Info(newLog(r.Context(), "Invalid update member request made. Reasons: %s", msg))
+		problem.Write(rw, r, problem.ValidationFailed, msg, o.val.FieldErrors(err)...)
+		return
+	}
+
+	current, err := o.connection.GetMembership(r.Context(), caller.OrganizationID, memberID)
+	if errors.Is(err, data.ErrNotFound) {
+		o.logger.Info(newLog(r.Context(), "Unable to find member %s of organization %s", memberID, caller.OrganizationID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find organization member")
+		return
+	}
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to find organization member: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find organization member")
+		return
+	}
+
+	if !model.RoleAtLeast(caller.Role, current.Role) || !model.RoleAtLeast(caller.Role, input.Role) {
+		o.logger.Info(newLog(r.Context(), "A member with the %s role can not change the %s role to %s", caller.Role, current.Role, input.Role))
+		problem.Write(rw, r, problem.Forbidden, "Unable to change the role of a member more privileged than you, or to a role more privileged than yours")
+		return
+	}
+
+	member, err := o.connection.UpdateMember(r.Context(), caller.OrganizationID, memberID, input.Role)
+	if errors.Is(err, data.ErrNotFound) {
+		o.logger.Info(newLog(r.Context(), "Unable to find member %s of organization %s", memberID, caller.OrganizationID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find organization member")
+		return
+	}
+	if errors.Is(err, data.ErrLastOwner) {
+		o.logger.Info(newLog(r.Context(), "Refused to demote the last owner of organization %s", caller.OrganizationID))
+		problem.Write(rw, r, problem.Conflict, "An organization must have at least one owner")
+		return
+	}
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to update organization member: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to update organization member")
+		return
+	}
+
+	o.logger.Info(newLog(r.Context(), "Changed user %s in organization %s to %s", memberID, caller.OrganizationID, input.Role))
+	recordAudit(o.logger, o.connection, r, userID, model.AuditUpdate, "organization_member", memberAuditID(member), current, member)
+
+	o.writeJSON(http.StatusOK, member, rw, r)
+}
+
+// RemoveMember handles removing a user from an organization. Admins and owners can remove members no more privileged than
+// themselves, and anyone can leave.
+func (o *Organization) RemoveMember(userID string, rw http.ResponseWriter, r *http.Request) {
+	o.logger.Info(newLog(r.Context(), "Remove organization member request made at %s", r.URL.String()))
+
+	memberID := mux.Vars(r)["user_id"]
+
+	min := model.RoleAdmin
+	if memberID == userID {
+		min = model.RoleMember
+	}
+
+	caller, ok := o.membership(userID, min, rw, r)
+	if !ok {
+		return
+	}
+
+	current, err := o.connection.GetMembership(r.Context(), caller.OrganizationID, memberID)
+	if errors.Is(err, data.ErrNotFound) {
+		o.logger.Info(newLog(r.Context(), "Unable to find member %s of organization %s", memberID, caller.OrganizationID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find organization member")
+		return
+	}
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to find organization member: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find organization member")
+		return
+	}
+
+	if !model.RoleAtLeast(caller.Role, current.Role) {
+		o.logger.Info(newLog(r.Context(), "A member with the %s role can not remove one with the %s role", caller.Role, current.Role))
+		problem.Write(rw, r, problem.Forbidden, "Unable to remove a member more privileged than you")
+		return
+	}
+
+	err = o.connection.RemoveMember(r.Context(), caller.OrganizationID, memberID)
+	if errors.Is(err, data.ErrNotFound) {
+		o.logger.Info(newLog(r.Context(), "Unable to find member %s of organization %s", memberID, caller.OrganizationID))
+		problem.Write(rw, r, problem.NotFound, "Failed to find organization member")
+		return
+	}
+	if errors.Is(err, data.ErrLastOwner) {
+		o.logger.Info(newLog(r.Context(), "Refused to remove the last owner of organization %s", caller.OrganizationID))
+		problem.Write(rw, r, problem.Conflict, "An organization must have at least one owner")
+		return
+	}
+	if err != nil {
+		o.logger.Warning(newLog(r.Context(), "Unable to remove organization member: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to remove organization member")
+		return
+	}
+
+	o.logger.Info(newLog(r.Context(), "Removed user %s from organization %s", memberID, caller.OrganizationID))
+	recordAudit(o.logger, o.connection, r, userID, model.AuditDelete, "organization_member", memberAuditID(current), current, nil)
+
+	rw.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(rw, "%s", "organization member removed")
+}
+
+// memberAuditID identifies a membership in the audit log by its organization and user
+func memberAuditID(member model.Membership) string {
+	return member.OrganizationID + "/" + member.UserID
+}
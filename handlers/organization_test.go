@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/gorilla/mux"
+)
+
+// testOrganization is an organization with a user in each role, plus users who don't belong to it
+type testOrganization struct {
+	db    data.Connection
+	h     *Organization
+	ID    string
+	users map[string]string
+}
+
+// newTestOrganization creates an organization owned by owner, with admin and member in those roles. outsider and
+// newcomer are registered but don't belong to it.
+func newTestOrganization(t *testing.T) testOrganization {
+	t.Helper()
+
+	db := data.NewMemory(newTestLogger())
+	users := map[string]string{}
+	for _, username := range []string{"owner", "admin", "member", "outsider", "newcomer"} {
+		user, err := db.CreateUser(context.Background(), username, testPassword)
+		if err != nil {
+			t.Fatalf("Unable to create user %s: %s", username, err)
+		}
+		users[username] = user.ID
+	}
+
+	org, err := db.CreateOrganization(context.Background(), users["owner"], "platform")
+	if err != nil {
+		t.Fatalf("CreateOrganization: %s", err)
+	}
+	for _, role := range []string{model.RoleAdmin, model.RoleMember} {
+		_, err := db.AddMember(context.Background(), model.Membership{OrganizationID: org.ID, UserID: users[role], Role: role})
+		if err != nil {
+			t.Fatalf("AddMember %s: %s", role, err)
+		}
+	}
+
+	return testOrganization{db, NewOrganization(newTestLogger(), newTestValidator(), db), org.ID, users}
+}
+
+// serve calls an organization handler as the named user, for the member named by the user_id path variable if set
+func (o testOrganization) serve(handler func(string, http.ResponseWriter, *http.Request), method string, caller string, member string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/organizations/"+o.ID, strings.NewReader(body))
+	vars := map[string]string{"id": o.ID}
+	if member != "" {
+		vars["user_id"] = o.users[member]
+	}
+	r = mux.SetURLVars(r, vars)
+
+	rw := httptest.NewRecorder()
+	handler(o.users[caller], rw, r)
+	return rw
+}
+
+func TestOrganizationRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller string
+		call   func(o testOrganization, caller string) *httptest.ResponseRecorder
+		want   int
+	}{
+		{"owner adds a member", "owner", addMember("newcomer", model.RoleMember), http.StatusCreated},
+		{"admin adds a member", "admin", addMember("newcomer", model.RoleMember), http.StatusCreated},
+		{"member can't add members", "member", addMember("newcomer", model.RoleMember), http.StatusForbidden},
+		{"non-member can't add members", "outsider", addMember("newcomer", model.RoleMember), http.StatusNotFound},
+		{"owner adds an owner", "owner", addMember("newcomer", model.RoleOwner), http.StatusCreated},
+		{"admin can't add an owner", "admin", addMember("newcomer", model.RoleOwner), http.StatusForbidden},
+		{"admin promotes a member", "admin", updateMember("member", model.RoleAdmin), http.StatusOK},
+		{"admin can't demote the owner", "admin", updateMember("owner", model.RoleMember), http.StatusForbidden},
+		{"member can't change roles", "member", updateMember("member", model.RoleAdmin), http.StatusForbidden},
+		{"owner can't demote the last owner", "owner", updateMember("owner", model.RoleAdmin), http.StatusConflict},
+		{"admin removes a member", "admin", removeMember("member"), http.StatusOK},
+		{"admin can't remove the owner", "admin", removeMember("owner"), http.StatusForbidden},
+		{"member can't remove others", "member", removeMember("admin"), http.StatusForbidden},
+		{"member leaves", "member", removeMember("member"), http.StatusOK},
+		{"non-member can't leave", "outsider", removeMember("outsider"), http.StatusNotFound},
+		{"owner can't leave as the last owner", "owner", removeMember("owner"), http.StatusConflict},
+		{"owner deletes the organization", "owner", deleteOrganization, http.StatusOK},
+		{"admin can't delete the organization", "admin", deleteOrganization, http.StatusForbidden},
+		{"non-member can't see the organization", "outsider", getOrganization, http.StatusNotFound},
+		{"member sees the organization", "member", getOrganization, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newTestOrganization(t)
+
+			rw := tt.call(o, tt.caller)
+			if rw.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rw.Code, tt.want, rw.Body.String())
+			}
+		})
+	}
+}
+
+func addMember(username string, role string) func(testOrganization, string) *httptest.ResponseRecorder {
+	return func(o testOrganization, caller string) *httptest.ResponseRecorder {
+		return o.serve(o.h.AddMember, http.MethodPost, caller, "", `{"username": "`+username+`", "role": "`+role+`"}`)
+	}
+}
+
+func updateMember(member string, role string) func(testOrganization, string) *httptest.ResponseRecorder {
+	return func(o testOrganization, caller string) *httptest.ResponseRecorder {
+		return o.serve(o.h.UpdateMember, http.MethodPut, caller, member, `{"role": "`+role+`"}`)
+	}
+}
+
+func removeMember(member string) func(testOrganization, string) *httptest.ResponseRecorder {
+	return func(o testOrganization, caller string) *httptest.ResponseRecorder {
+		return o.serve(o.h.RemoveMember, http.MethodDelete, caller, member, "")
+	}
+}
+
+func deleteOrganization(o testOrganization, caller string) *httptest.ResponseRecorder {
+	return o.serve(o.h.DeleteOrganization, http.MethodDelete, caller, "", "")
+}
+
+func getOrganization(o testOrganization, caller string) *httptest.ResponseRecorder {
+	return o.serve(o.h.GetOrganization, http.MethodGet, caller, "", "")
+}
+
+func TestOrganizationResources(t *testing.T) {
+	o := newTestOrganization(t)
+	h := newLambdaHandler(o.db)
+
+	// as calls a resource handler as the named user, acting on behalf of the organization
+	as := func(caller string, handler func(string, http.ResponseWriter, *http.Request), method string, ID string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/lambdas/"+ID, strings.NewReader(body))
+		r = r.WithContext(auth.ContextWithOrganization(r.Context(), o.ID))
+		if ID != "" {
+			r = mux.SetURLVars(r, map[string]string{"id": ID})
+		}
+		rw := httptest.NewRecorder()
+		handler(o.users[caller], rw, r)
+		return rw
+	}
+
+	rw := as("owner", h.CreateResource, http.MethodPost, "", `{"name": "shared-lambda", "concurrent_limit": 1}`)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("create returned %d: %s", rw.Code, rw.Body.String())
+	}
+	if !strings.Contains(rw.Body.String(), `"organization_id":"`+o.ID+`"`) {
+		t.Errorf("created lambda does not belong to the organization: %s", rw.Body.String())
+	}
+	created := map[string]interface{}{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &created); err != nil {
+		t.Fatalf("create returned invalid JSON: %s", err)
+	}
+	ID := created["id"].(string)
+
+	rw = as("member", h.GetResources, http.MethodGet, "", "")
+	if !strings.Contains(rw.Body.String(), ID) {
+		t.Errorf("member's list is missing the organization's lambda: %s", rw.Body.String())
+	}
+
+	rw = as("member", h.UpdateResource, http.MethodPut, ID, `{"name": "shared-lambda", "concurrent_limit": 2}`)
+	if rw.Code != http.StatusOK {
+		t.Errorf("member update returned %d: %s", rw.Code, rw.Body.String())
+	}
+
+	rw = serve(h.GetResource, http.MethodGet, ID, "", nil)
+	checkProblem(t, rw, problem.NotFound)
+
+	rw = as("outsider", h.UpdateResource, http.MethodPut, ID, `{"name": "shared-lambda", "concurrent_limit": 3}`)
+	checkProblem(t, rw, problem.NotFound)
+
+	rw = as("member", h.DeleteResource, http.MethodDelete, ID, "")
+	if rw.Code != http.StatusOK {
+		t.Errorf("member delete returned %d: %s", rw.Code, rw.Body.String())
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// mergePatchContentType is the media type of JSON merge patches, described in RFC 7396
+const mergePatchContentType = "application/merge-patch+json"
+
+// isMergePatch reports whether the request body is a JSON merge patch
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == mergePatchContentType
+}
+
+// mergePatch applies a JSON merge patch to target, returning the result. Members of patch which are null are
+// removed from target, objects are merged recursively, and any other value replaces the target.
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	merged, ok := target.(map[string]interface{})
+	if !ok {
+		merged = map[string]interface{}{}
+	}
+
+	for key, value := range members {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = mergePatch(merged[key], value)
+	}
+
+	return merged
+}
+
+// toJSONObject converts v to the generic form of the JSON object it encodes to
+func toJSONObject(v interface{}) (map[string]interface{}, error) {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	object := map[string]interface{}{}
+	err = json.Unmarshal(d, &object)
+	return object, err
+}
+
+// applyMergePatch reads a JSON merge patch from the request body, applies it to current and decodes the result
+// into merged, which must be a pointer to the same type as current. Fields which current does not have are refused.
+func applyMergePatch(r *http.Request, current interface{}, merged interface{}) error {
+	patch := map[string]interface{}{}
+
+	err := json.NewDecoder(r.Body).Decode(&patch)
+	if err != nil {
+		return fmt.Errorf("Unable to parse request body, a patch must be a JSON object")
+	}
+
+	target, err := toJSONObject(current)
+	if err != nil {
+		return err
+	}
+
+	d, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return err
+	}
+
+	de := json.NewDecoder(bytes.NewReader(d))
+	de.DisallowUnknownFields()
+	err = de.Decode(merged)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("Unable to apply patch: %s can not be a %s", typeErr.Field, typeErr.Value)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to apply patch: %s", err.Error())
+	}
+
+	return nil
+}
+
+// changedFields returns the sorted names of the JSON fields whose value differs between before and after
+func changedFields(before interface{}, after interface{}) ([]string, error) {
+	from, err := toJSONObject(before)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := toJSONObject(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []string{}
+	for field, value := range to {
+		if !reflect.DeepEqual(from[field], value) {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+
+	return changed, nil
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/problem"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %s", s, err)
+	}
+	return v
+}
+
+// TestMergePatch checks the examples of RFC 7396 appendix A
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.patch, func(t *testing.T) {
+			got := mergePatch(decodeJSON(t, tt.target), decodeJSON(t, tt.patch))
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("mergePatch = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPatchResource(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		want        map[string]interface{}
+		wantType    *problem.Type
+	}{
+		{
+			name:  "changes only the given field",
+			patch: `{"concurrent_limit": 9}`,
+			want:  map[string]interface{}{"name": "alpha-lambda", "concurrent_limit": 9.0, "tags": map[string]interface{}{"env": "dev"}},
+		},
+		{
+			name:  "merges tags",
+			patch: `{"tags": {"team": "core"}}`,
+			want:  map[string]interface{}{"tags": map[string]interface{}{"env": "dev", "team": "core"}},
+		},
+		{
+			name:  "null removes a tag",
+			patch: `{"tags": {"env": null}}`,
+			want:  map[string]interface{}{"tags": map[string]interface{}{}},
+		},
+		{
+			name:  "null removes every tag",
+			patch: `{"tags": null}`,
+			want:  map[string]interface{}{"tags": map[string]interface{}{}},
+		},
+		{
+			name:  "empty patch changes nothing",
+			patch: `{}`,
+			want:  map[string]interface{}{"name": "alpha-lambda", "concurrent_limit": 1.0},
+		},
+		{
+			name:     "null required field",
+			patch:    `{"concurrent_limit": null}`,
+			wantType: &problem.ValidationFailed,
+		},
+		{
+			name:     "unknown field",
+			patch:    `{"colour": "red"}`,
+			wantType: &problem.InvalidRequest,
+		},
+		{
+			name:     "wrong type",
+			patch:    `{"concurrent_limit": "lots"}`,
+			wantType: &problem.InvalidRequest,
+		},
+		{
+			name:     "not an object",
+			patch:    `[1]`,
+			wantType: &problem.InvalidRequest,
+		},
+		{
+			name:        "not a merge patch",
+			contentType: "application/json",
+			patch:       `{"concurrent_limit": 9}`,
+			wantType:    &problem.UnsupportedMediaType,
+		},
+		{
+			name:     "taken name",
+			patch:    `{"name": "bravo-lambda"}`,
+			wantType: &problem.AlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newLambdaHandler(data.NewMemory(newTestLogger()))
+			created, _ := createLambda(t, h, `{"name": "alpha-lambda", "concurrent_limit": 1, "tags": {"env": "dev"}}`)
+			createLambda(t, h, `{"name": "bravo-lambda", "concurrent_limit": 1}`)
+
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = mergePatchContentType + "; charset=utf-8"
+			}
+
+			rw := serve(h.PatchResource, http.MethodPatch, created["id"].(string), tt.patch, map[string]string{"Content-Type": contentType})
+			if tt.wantType != nil {
+				checkProblem(t, rw, *tt.wantType)
+				return
+			}
+			if rw.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rw.Code, rw.Body.String())
+			}
+
+			patched := map[string]interface{}{}
+			if err := json.Unmarshal(rw.Body.Bytes(), &patched); err != nil {
+				t.Fatalf("invalid JSON: %s", err)
+			}
+			for field, want := range tt.want {
+				if !reflect.DeepEqual(patched[field], want) {
+					t.Errorf("%s = %v, want %v", field, patched[field], want)
+				}
+			}
+		})
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	type body struct {
+		Name  string            `json:"name"`
+		Limit int               `json:"limit"`
+		Tags  map[string]string `json:"tags"`
+	}
+
+	before := body{Name: "a", Limit: 1, Tags: map[string]string{"env": "dev"}}
+
+	tests := []struct {
+		name  string
+		after body
+		want  []string
+	}{
+		{"unchanged", before, []string{}},
+		{"one field", body{Name: "a", Limit: 2, Tags: map[string]string{"env": "dev"}}, []string{"limit"}},
+		{"sorted", body{Name: "b", Limit: 1, Tags: map[string]string{}}, []string{"name", "tags"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := changedFields(before, tt.after)
+			if err != nil {
+				t.Fatalf("changedFields: %s", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changedFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
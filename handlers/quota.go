@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/problem"
+)
+
+// Quota contains handler data for a user's quotas
+type Quota struct {
+	logger     logs.Logger
+	connection data.Connection
+}
+
+// NewQuota creates a new Quota
+func NewQuota(logger logs.Logger, connection data.Connection) *Quota {
+	return &Quota{logger, connection}
+}
+
+// GetQuotas handles fetching the user's usage of every resource type against their quotas
+func (q *Quota) GetQuotas(userID string, rw http.ResponseWriter, r *http.Request) {
+	q.logger.Info(newLog(r.Context(), "Get quotas request made at %s", r.URL.String()))
+
+	usages, err := q.connection.GetQuotaUsage(r.Context(), userID)
+	if err != nil {
+		q.logger.Warning(newLog(r.Context(), "Unable to find quotas: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to find quotas")
+		return
+	}
+
+	data, err := usages.ToJSON()
+	if err != nil {
+		q.logger.Error(newLog(r.Context(), "Failed to parse quotas to JSON: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Failed to correctly parse quotas to JSON")
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(data)
+}
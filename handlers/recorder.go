@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// responseRecorder passes a response through to the client while keeping a copy of its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder(rw http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: rw, status: http.StatusOK}
+}
+
+// WriteHeader records the status code before sending it
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the body before sending it
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header a request ID is accepted from and returned on
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits the IDs accepted from clients to something safe to log
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID assigns every request an ID, accepting the client's X-Request-ID header if it has one.
+// The ID is returned on the response and attached to every log made with the request context.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.New().String()
+		}
+
+		rw.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(rw, r.WithContext(logs.ContextWithField(r.Context(), logs.RequestIDField, id)))
+	})
+}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := logs.ContextField(ctx, logs.RequestIDField)
+	s, _ := id.(string)
+	return s
+}
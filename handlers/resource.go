@@ -3,36 +3,31 @@ package handlers
 import (
 	"net/http"
 
-	"github.com/danielpadmore/cloudygo-service/data"
 	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/danielpadmore/cloudygo-service/registry"
 )
 
 // Resource contains handler data for a single resource
 type Resource struct {
-	logger     logs.Logger
-	connection data.Connection
+	logger logs.Logger
 }
 
 // NewResource creates a new Resource
-func NewResource(logger logs.Logger, connection data.Connection) *Resource {
-	return &Resource{logger, connection}
+func NewResource(logger logs.Logger) *Resource {
+	return &Resource{logger}
 }
 
 // ServeHTTP handles fetching all resources available
 func (resource *Resource) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	resource.logger.Info(newLog("Request received at url %s", r.URL.String()))
+	resource.logger.Info(newLog(r.Context(), "Request received at url %s", r.URL.String()))
 
-	resources, err := resource.connection.GetResources()
-	if err != nil {
-		resource.logger.Error(newLog("Resources not found"))
-		http.Error(rw, "Unable to find resources", http.StatusInternalServerError)
-		return
-	}
+	resources := registry.Catalog()
 
 	data, err := resources.ToJSON()
 	if err != nil {
-		resource.logger.Error(newLog("Failed to parse found resources: %s", err.Error()))
-		http.Error(rw, "Failed to correctly parse resources", http.StatusInternalServerError)
+		resource.logger.Error(newLog(r.Context(), "Failed to parse found resources: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Failed to correctly parse resources")
 		return
 	}
 
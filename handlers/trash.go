@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/reaper"
+)
+
+// parseResourceListOptions reads list options like parseListOptions. When deleted=true is given, the resources
+// deleted within the retention period are listed instead of live ones, as they are the ones which can be restored.
+func parseResourceListOptions(r *http.Request, fields map[string]fieldKind, trash func() reaper.Config) (model.ListOptions, error) {
+	query := r.URL.Query()
+	deleted := query.Get("deleted")
+	query.Del("deleted")
+
+	opts, err := parseListQuery(r, query, fields)
+	if err != nil {
+		return opts, err
+	}
+
+	if deleted != "" {
+		listDeleted, err := strconv.ParseBool(deleted)
+		if err != nil {
+			return opts, fmt.Errorf("deleted must be true or false")
+		}
+		if listDeleted {
+			opts.DeletedWithin = trash().RetentionPeriod()
+		}
+	}
+
+	return opts, nil
+}
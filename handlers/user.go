@@ -2,123 +2,384 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"time"
+	"strings"
 
+	"github.com/danielpadmore/cloudygo-service/auth"
 	"github.com/danielpadmore/cloudygo-service/data"
 	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/danielpadmore/cloudygo-service/validation"
 	"github.com/dgrijalva/jwt-go"
 )
 
-// JWTSecret is an arbitrary secret
-const JWTSecret = "dennis_the_menace"
-
 // User contains database connection data
 type User struct {
 	logger     logs.Logger
+	val        validation.Validator
 	connection data.Connection
+	keys       *auth.KeySet
+	lockout    func() auth.LockoutConfig
 }
 
 // AuthData describes the shape of inbound authentication details
 type AuthData struct {
-	Username string `json:"username,omitempty"`
-	Password string `json:"password,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+type registerRequestBody struct {
+	Username string `json:"username" validate:"required,min=3,max=64,username"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+type changePasswordRequestBody struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,password"`
 }
 
 // AuthResponse describes outbound authenticated data
 type AuthResponse struct {
-	UserID   string `json:"user_id,omitempty"`
-	Username string `json:"username,omitempty"`
-	Token    string `json:"token,omitempty"`
+	UserID       string `json:"user_id,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Token        string `json:"token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	PasswordResetRequired bool `json:"password_reset_required,omitempty"`
 }
 
-// NewUser creates a new user
-func NewUser(logger logs.Logger, connection data.Connection) *User {
-	return &User{logger, connection}
+// RefreshData describes the shape of inbound refresh token details
+type RefreshData struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
-// No handling for root of this route
-func (user *User) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	user.logger.Debug(newLog("Attempted request at invalid user endpoint: %s", r.URL.String()))
-	http.NotFound(rw, r)
+// NewUser creates a new user. Each sign in uses the lockout config returned by lockout at the time.
+func NewUser(logger logs.Logger, val validation.Validator, connection data.Connection, keys *auth.KeySet, lockout func() auth.LockoutConfig) *User {
+	return &User{logger, val, connection, keys, lockout}
 }
 
 // Register is a handler to register a new user
 func (user *User) Register(rw http.ResponseWriter, r *http.Request) {
-	user.logger.Info(newLog("Register request made at %s", r.URL.String()))
-	body := AuthData{}
+	user.logger.Info(newLog(r.Context(), "Register request made at %s", r.URL.String()))
+	body := registerRequestBody{}
 
 	err := json.NewDecoder(r.Body).Decode(&body)
 	if err != nil {
-		user.logger.Info(newLog("Unable to parse register body: %s", err.Error()))
-		http.Error(rw, "Unable to parse request", http.StatusBadRequest)
+		user.logger.Info(newLog(r.Context(), "Unable to parse register body: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, "Unable to parse request")
+		return
+	}
+
+	if err := user.val.Validate.Struct(body); err != nil {
+		msg := user.val.ConcatReasons(err)
+		user.logger.Info(newLog(r.Context(), "Invalid register request made. Reasons: %s", msg))
+		problem.Write(rw, r, problem.ValidationFailed, msg, user.val.FieldErrors(err)...)
 		return
 	}
 
-	u, err := user.connection.CreateUser(body.Username, body.Password)
+	u, err := user.connection.CreateUser(r.Context(), body.Username, body.Password)
+	if errors.Is(err, data.ErrAlreadyExists) {
+		user.logger.Info(newLog(r.Context(), "Unable to register user %s: %s", body.Username, err.Error()))
+		problem.Write(rw, r, problem.AlreadyExists, fmt.Sprintf("Username %s is already taken", body.Username))
+		return
+	}
 	if err != nil {
-		user.logger.Info(newLog("Unable to register user %s: %s", body.Username, err.Error()))
-		http.Error(rw, fmt.Sprintf("Unable to register user %s", body.Username), http.StatusBadRequest)
+		user.logger.Warning(newLog(r.Context(), "Unable to register user %s: %s", body.Username, err.Error()))
+		problem.Write(rw, r, problem.Internal, fmt.Sprintf("Unable to register user %s", body.Username))
 		return
 	}
 
-	tokenString, err := generateJWTToken(u.ID, u.Username)
+	recordAudit(user.logger, user.connection, r, u.ID, model.AuditRegister, "user", u.ID, nil, nil)
+
+	res, err := user.issueTokens(r, u, []string{auth.FullAccess})
 	if err != nil {
-		user.logger.Error(newLog("Unable to generate JWT token"))
-		http.Error(rw, "Unable to generate JWT token", http.StatusInternalServerError)
+		user.logger.Error(newLog(r.Context(), "Unable to generate JWT token: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to generate JWT token")
 		return
 	}
 
 	rw.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(rw).Encode(AuthResponse{
-		UserID:   u.ID,
-		Username: u.Username,
-		Token:    tokenString,
-	})
+	json.NewEncoder(rw).Encode(res)
 
 }
 
 // SignIn authenticates and returns a new JWT token with user details
 func (user *User) SignIn(rw http.ResponseWriter, r *http.Request) {
-	user.logger.Info(newLog("Sign in request made at %s", r.URL.String()))
+	user.logger.Info(newLog(r.Context(), "Sign in request made at %s", r.URL.String()))
 
 	body := AuthData{}
 
 	err := json.NewDecoder(r.Body).Decode(&body)
 	if err != nil {
-		user.logger.Info(newLog("Unable to parse sign in body: %s", err.Error()))
-		http.Error(rw, "Unable to parse request", http.StatusBadRequest)
+		user.logger.Info(newLog(r.Context(), "Unable to parse sign in body: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, "Unable to parse request")
+		return
+	}
+
+	scopes := body.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{auth.FullAccess}
+	}
+	for _, scope := range scopes {
+		if err := auth.ValidateScope(scope); err != nil {
+			user.logger.Info(newLog(r.Context(), "Invalid sign in request made: %s", err.Error()))
+			problem.Write(rw, r, problem.InvalidRequest, err.Error())
+			return
+		}
+	}
+
+	if !user.checkLockout(body.Username, rw, r) {
+		return
+	}
+
+	u, err := user.connection.AuthenticateUser(r.Context(), body.Username, body.Password)
+	if err != nil {
+		user.logger.Info(newLog(r.Context(), "Unable to sign in user %s: %s", body.Username, err.Error()))
+		user.recordLoginFailure(body.Username, r)
+		problem.Write(rw, r, problem.Unauthorized, "Invalid creds, try again!")
+		return
+	}
+
+	user.clearLoginFailures(body.Username, r)
+
+	if u.DisabledAt.Valid {
+		user.logger.Info(newLog(r.Context(), "Refused sign in of disabled user %s", u.ID))
+		problem.Write(rw, r, problem.Forbidden, "Account disabled")
+		return
+	}
+
+	recordAudit(user.logger, user.connection, r, u.ID, model.AuditSignIn, "user", u.ID, nil, nil)
+
+	res, err := user.issueTokens(r, u, scopes)
+	if err != nil {
+		user.logger.Error(newLog(r.Context(), "Unable to generate JWT token: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to generate JWT token")
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(res)
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh token. Each refresh token can only be used once.
+func (user *User) Refresh(rw http.ResponseWriter, r *http.Request) {
+	user.logger.Info(newLog(r.Context(), "Refresh request made at %s", r.URL.String()))
+
+	body := RefreshData{}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.RefreshToken == "" {
+		user.logger.Info(newLog(r.Context(), "Unable to parse refresh body"))
+		problem.Write(rw, r, problem.InvalidRequest, "Unable to parse request")
+		return
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		user.logger.Error(newLog(r.Context(), "Unable to generate refresh token: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to generate refresh token")
 		return
 	}
 
-	u, err := user.connection.AuthenticateUser(body.Username, body.Password)
+	stored, err := user.connection.RotateRefreshToken(r.Context(), auth.HashToken(body.RefreshToken), refreshHash, user.keys.RefreshTokenTTL())
+	if errors.Is(err, data.ErrNotFound) {
+		user.logger.Info(newLog(r.Context(), "Refresh token is invalid, expired or revoked"))
+		problem.Write(rw, r, problem.Unauthorized, "Invalid refresh token")
+		return
+	}
+	if err != nil {
+		user.logger.Error(newLog(r.Context(), "Unable to refresh token: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to refresh token")
+		return
+	}
+
+	u, err := user.connection.GetUser(r.Context(), stored.UserID)
+	if errors.Is(err, data.ErrNotFound) {
+		user.logger.Info(newLog(r.Context(), "User %s no longer exists", stored.UserID))
+		problem.Write(rw, r, problem.Unauthorized, "Invalid refresh token")
+		return
+	}
 	if err != nil {
-		user.logger.Info(newLog("Unable to sign in user %s: %s", body.Username, err.Error()))
-		http.Error(rw, "Invalid creds, try again!", http.StatusUnauthorized)
+		user.logger.Error(newLog(r.Context(), "Unable to find user %s: %s", stored.UserID, err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to refresh token")
 		return
 	}
 
-	tokenString, err := generateJWTToken(u.ID, u.Username)
+	tokenString, err := user.generateJWTToken(u.ID, u.Username, stored.Scopes)
 	if err != nil {
-		user.logger.Info(newLog("Unable to generate JWT token: %s", err.Error()))
-		http.Error(rw, "Unable to generate JWT token", http.StatusInternalServerError)
+		user.logger.Error(newLog(r.Context(), "Unable to generate JWT token: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to generate JWT token")
 		return
 	}
 
 	rw.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(rw).Encode(AuthResponse{
-		UserID:   u.ID,
-		Username: u.Username,
-		Token:    tokenString,
+		UserID:       u.ID,
+		Username:     u.Username,
+		Token:        tokenString,
+		ExpiresIn:    int(user.keys.TokenTTL().Seconds()),
+		RefreshToken: refreshToken,
 	})
 }
 
-func generateJWTToken(id string, username string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+// SignOut revokes the access token the request was made with, and the refresh token in the body if one is sent
+func (user *User) SignOut(userID string, rw http.ResponseWriter, r *http.Request) {
+	user.logger.Info(newLog(r.Context(), "Sign out request made at %s", r.URL.String()))
+
+	claims := auth.ClaimsFromContext(r.Context())
+	if auth.TokenID(claims) == "" {
+		user.logger.Info(newLog(r.Context(), "Sign out requested without a token"))
+		problem.Write(rw, r, problem.InvalidRequest, "Sign out requires a token, revoke API keys at /api-keys instead")
+		return
+	}
+
+	body := RefreshData{}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil && err != io.EOF {
+		user.logger.Info(newLog(r.Context(), "Unable to parse sign out body: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, "Unable to parse request")
+		return
+	}
+
+	if body.RefreshToken != "" {
+		err = user.connection.RevokeRefreshToken(r.Context(), userID, auth.HashToken(body.RefreshToken))
+		if errors.Is(err, data.ErrNotFound) {
+			user.logger.Info(newLog(r.Context(), "Refresh token was already invalid, expired or revoked"))
+		} else if err != nil {
+			user.logger.Error(newLog(r.Context(), "Unable to revoke refresh token: %s", err.Error()))
+			problem.Write(rw, r, problem.Internal, "Unable to sign out")
+			return
+		}
+	}
+
+	err = user.connection.RevokeToken(r.Context(), auth.TokenID(claims), auth.ExpiresAt(claims))
+	if err != nil {
+		user.logger.Error(newLog(r.Context(), "Unable to revoke access token: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to sign out")
+		return
+	}
+
+	user.logger.Info(newLog(r.Context(), "Signed out user %s", userID))
+	recordAudit(user.logger, user.connection, r, userID, model.AuditSignOut, "user", userID, nil, nil)
+
+	rw.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(rw, "%s", "signed out")
+}
+
+// ChangePassword sets a new password for the user once they have confirmed their current one. Every other session of
+// the user is signed out, so new tokens with the same scopes are returned. API keys are kept.
+func (user *User) ChangePassword(userID string, rw http.ResponseWriter, r *http.Request) {
+	user.logger.Info(newLog(r.Context(), "Change password request made at %s", r.URL.String()))
+
+	if auth.TokenID(auth.ClaimsFromContext(r.Context())) == "" {
+		user.logger.Info(newLog(r.Context(), "Password change requested without a token"))
+		problem.Write(rw, r, problem.InvalidRequest, "Changing password requires a token")
+		return
+	}
+
+	body := changePasswordRequestBody{}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		user.logger.Info(newLog(r.Context(), "Unable to parse change password body: %s", err.Error()))
+		problem.Write(rw, r, problem.InvalidRequest, "Unable to parse request")
+		return
+	}
+
+	if err := user.val.Validate.Struct(body); err != nil {
+		msg := user.val.ConcatReasons(err)
+		user.logger.Info(newLog(r.Context(), "Invalid change password request made. Reasons: %s", msg))
+		problem.Write(rw, r, problem.ValidationFailed, msg, user.val.FieldErrors(err)...)
+		return
+	}
+
+	if body.NewPassword == body.OldPassword {
+		user.logger.Info(newLog(r.Context(), "Invalid change password request made. Reasons: password unchanged"))
+		problem.Write(rw, r, problem.InvalidRequest, "new_password must be different to old_password")
+		return
+	}
+
+	u, err := user.connection.GetUser(r.Context(), userID)
+	if err != nil {
+		user.logger.Error(newLog(r.Context(), "Unable to find user %s: %s", userID, err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to change password")
+		return
+	}
+
+	if !user.checkLockout(u.Username, rw, r) {
+		return
+	}
+
+	_, err = user.connection.AuthenticateUser(r.Context(), u.Username, body.OldPassword)
+	if err != nil {
+		user.logger.Info(newLog(r.Context(), "Unable to confirm password of user %s: %s", userID, err.Error()))
+		user.recordLoginFailure(u.Username, r)
+		problem.Write(rw, r, problem.Forbidden, "Incorrect old_password")
+		return
+	}
+
+	user.clearLoginFailures(u.Username, r)
+
+	err = user.connection.ChangePassword(r.Context(), userID, body.NewPassword)
+	if err != nil {
+		user.logger.Error(newLog(r.Context(), "Unable to change password of user %s: %s", userID, err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to change password")
+		return
+	}
+
+	user.logger.Info(newLog(r.Context(), "Changed password of user %s", userID))
+	recordAudit(user.logger, user.connection, r, userID, model.AuditChangePassword, "user", userID, nil, nil)
+
+	u.PasswordResetRequired = false
+	res, err := user.issueTokens(r, u, auth.ScopesFromContext(r.Context()))
+	if err != nil {
+		user.logger.Error(newLog(r.Context(), "Unable to generate JWT token: %s", err.Error()))
+		problem.Write(rw, r, problem.Internal, "Unable to generate JWT token")
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(res)
+}
+
+// issueTokens returns a new access token and refresh token granting scopes to a user who has just authenticated
+func (user *User) issueTokens(r *http.Request, u model.User, scopes []string) (AuthResponse, error) {
+	tokenString, err := user.generateJWTToken(u.ID, u.Username, scopes)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	err = user.connection.CreateRefreshToken(r.Context(), u.ID, refreshHash, scopes, user.keys.RefreshTokenTTL())
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	return AuthResponse{
+		UserID:                u.ID,
+		Username:              u.Username,
+		Token:                 tokenString,
+		ExpiresIn:             int(user.keys.TokenTTL().Seconds()),
+		RefreshToken:          refreshToken,
+		PasswordResetRequired: u.PasswordResetRequired,
+	}, nil
+}
+
+func (user *User) generateJWTToken(id string, username string, scopes []string) (string, error) {
+	return user.keys.Sign(jwt.MapClaims{
 		"user_id":  id,
 		"username": username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
+		"scope":    strings.Join(scopes, " "),
 	})
-	return token.SignedString([]byte(JWTSecret))
 }
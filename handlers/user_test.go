@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+)
+
+const testPassword = "password123"
+
+func newTestKeySet(t *testing.T) *auth.KeySet {
+	t.Helper()
+
+	keys, err := auth.NewKeySet(auth.Config{
+		SigningKeyID: "test",
+		Keys:         []auth.KeyConfig{{ID: "test", Algorithm: "HS256", Secret: "test-secret"}},
+	})
+	if err != nil {
+		t.Fatalf("Unable to create key set: %s", err)
+	}
+	return keys
+}
+
+// newUserHandler creates a user handler backed by db, with users alice and bob already registered
+func newUserHandler(t *testing.T, db data.Connection, lockout auth.LockoutConfig) *User {
+	t.Helper()
+
+	for _, username := range []string{"alice", "bob"} {
+		if _, err := db.CreateUser(context.Background(), username, testPassword); err != nil {
+			t.Fatalf("Unable to create user %s: %s", username, err)
+		}
+	}
+
+	return NewUser(newTestLogger(), newTestValidator(), db, newTestKeySet(t), func() auth.LockoutConfig { return lockout })
+}
+
+func signIn(user *User, remoteAddr string, username string, password string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/signin", strings.NewReader(fmt.Sprintf(`{"username": %q, "password": %q}`, username, password)))
+	r.RemoteAddr = remoteAddr
+	rw := httptest.NewRecorder()
+	user.SignIn(rw, r)
+	return rw
+}
+
+func refresh(user *User, refreshToken string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/token/refresh", strings.NewReader(fmt.Sprintf(`{"refresh_token": %q}`, refreshToken)))
+	rw := httptest.NewRecorder()
+	user.Refresh(rw, r)
+	return rw
+}
+
+func decodeAuthResponse(t *testing.T, rw *httptest.ResponseRecorder) AuthResponse {
+	t.Helper()
+
+	if rw.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rw.Code, rw.Body.String())
+	}
+
+	res := AuthResponse{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid auth response: %s", err)
+	}
+	if res.Token == "" || res.RefreshToken == "" {
+		t.Fatalf("auth response %+v is missing tokens", res)
+	}
+	return res
+}
+
+func TestRefreshRotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		use      func(signedIn AuthResponse, refreshed AuthResponse) string
+		wantType *problem.Type
+	}{
+		{
+			name: "replacement token",
+			use:  func(signedIn AuthResponse, refreshed AuthResponse) string { return refreshed.RefreshToken },
+		},
+		{
+			name:     "reused token",
+			use:      func(signedIn AuthResponse, refreshed AuthResponse) string { return signedIn.RefreshToken },
+			wantType: &problem.Unauthorized,
+		},
+		{
+			name:     "unknown token",
+			use:      func(signedIn AuthResponse, refreshed AuthResponse) string { return "not-a-token" },
+			wantType: &problem.Unauthorized,
+		},
+		{
+			name:     "missing token",
+			use:      func(signedIn AuthResponse, refreshed AuthResponse) string { return "" },
+			wantType: &problem.InvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newUserHandler(t, data.NewMemory(newTestLogger()), auth.LockoutConfig{})
+
+			signedIn := decodeAuthResponse(t, signIn(user, "10.0.0.1:1", "alice", testPassword))
+			refreshed := decodeAuthResponse(t, refresh(user, signedIn.RefreshToken))
+			if refreshed.RefreshToken == signedIn.RefreshToken {
+				t.Fatalf("refresh returned the same refresh token")
+			}
+
+			rw := refresh(user, tt.use(signedIn, refreshed))
+			if tt.wantType != nil {
+				checkProblem(t, rw, *tt.wantType)
+				return
+			}
+
+			res := decodeAuthResponse(t, rw)
+			if res.UserID != signedIn.UserID || res.Username != "alice" {
+				t.Errorf("refreshed user = %s %s, want %s alice", res.UserID, res.Username, signedIn.UserID)
+			}
+		})
+	}
+}
+
+func TestRefreshKeepsScopes(t *testing.T) {
+	user := newUserHandler(t, data.NewMemory(newTestLogger()), auth.LockoutConfig{})
+
+	r := httptest.NewRequest(http.MethodPost, "/signin", strings.NewReader(
+		fmt.Sprintf(`{"username": "alice", "password": %q, "scopes": ["lambdas:read"]}`, testPassword)))
+	rw := httptest.NewRecorder()
+	user.SignIn(rw, r)
+	signedIn := decodeAuthResponse(t, rw)
+
+	refreshed := decodeAuthResponse(t, refresh(user, signedIn.RefreshToken))
+
+	claims, err := user.keys.Parse(refreshed.Token)
+	if err != nil {
+		t.Fatalf("Unable to parse refreshed token: %s", err)
+	}
+	if scope := claims["scope"]; scope != "lambdas:read" {
+		t.Errorf("refreshed scope = %v, want lambdas:read", scope)
+	}
+}
+
+// failingUsers is a connection which can't create users
+type failingUsers struct {
+	data.Connection
+}
+
+func (failingUsers) CreateUser(context.Context, string, string) (model.User, error) {
+	return model.User{}, errors.New("connection refused")
+}
+
+func TestRegisterProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		db       func(data.Connection) data.Connection
+		username string
+		want     problem.Type
+	}{
+		{"taken username", func(db data.Connection) data.Connection { return db }, "alice", problem.AlreadyExists},
+		{"storage failure", func(db data.Connection) data.Connection { return failingUsers{db} }, "carol", problem.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newUserHandler(t, data.NewMemory(newTestLogger()), auth.LockoutConfig{})
+			user.connection = tt.db(user.connection)
+
+			r := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(fmt.Sprintf(`{"username": %q, "password": %q}`, tt.username, testPassword)))
+			rw := httptest.NewRecorder()
+			user.Register(rw, r)
+
+			checkProblem(t, rw, tt.want)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package logs
+
+import "context"
+
+type contextKey int
+
+const fieldsKey contextKey = iota
+
+// RequestIDField is the field the ID of the request a context belongs to is carried in
+const RequestIDField = "request_id"
+
+// ContextWithField returns a copy of ctx carrying a field, such as request_id, to attach to every log made with it
+func ContextWithField(ctx context.Context, key string, value interface{}) context.Context {
+	existing, _ := ctx.Value(fieldsKey).(map[string]interface{})
+
+	fields := make(map[string]interface{}, len(existing)+1)
+	for k, v := range existing {
+		fields[k] = v
+	}
+	fields[key] = value
+
+	return context.WithValue(ctx, fieldsKey, fields)
+}
+
+// ContextField returns a field carried by ctx
+func ContextField(ctx context.Context, key string) (interface{}, bool) {
+	fields, _ := ctx.Value(fieldsKey).(map[string]interface{})
+	v, ok := fields[key]
+	return v, ok
+}
+
+// WithContext returns a copy of the log with the fields carried by ctx
+func (log LogStruct) WithContext(ctx context.Context) LogStruct {
+	fields, _ := ctx.Value(fieldsKey).(map[string]interface{})
+	if len(fields) == 0 {
+		return log
+	}
+	return log.WithFields(fields)
+}
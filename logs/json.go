@@ -0,0 +1,98 @@
+package logs
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// JSONLogger implements Logger printing one JSON object per line, for shipping to a log collector
+type JSONLogger struct {
+	level  int
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// NewJSONLogger creates a new JSONLogger instance
+func NewJSONLogger(level int) *JSONLogger {
+	return &JSONLogger{level: level, stdout: os.Stdout, stderr: os.Stderr}
+}
+
+// SetLevel updates logger level
+func (logger *JSONLogger) SetLevel(level int) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.level = level
+}
+
+// Fatal prints fatal logs
+func (logger *JSONLogger) Fatal(log LogStruct) {
+	logger.print(LogLevelFatal, log)
+}
+
+// Error prints error logs
+func (logger *JSONLogger) Error(log LogStruct) {
+	logger.print(LogLevelError, log)
+}
+
+// Warning prints warning logs
+func (logger *JSONLogger) Warning(log LogStruct) {
+	logger.print(LogLevelWarning, log)
+}
+
+// Info prints info logs
+func (logger *JSONLogger) Info(log LogStruct) {
+	logger.print(LogLevelInfo, log)
+}
+
+// Debug prints debug logs
+func (logger *JSONLogger) Debug(log LogStruct) {
+	logger.print(LogLevelDebug, log)
+}
+
+// Verbose prints verbose logs
+func (logger *JSONLogger) Verbose(log LogStruct) {
+	logger.print(LogLevelVerbose, log)
+}
+
+// print writes the log with its fields alongside the timestamp, level, layer and message.
+// Fields never overwrite those four keys.
+func (logger *JSONLogger) print(level int, log LogStruct) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+
+	if logger.level < level {
+		return
+	}
+
+	entry := make(map[string]interface{}, len(log.Fields)+4)
+	for k, v := range log.Fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		entry[k] = v
+	}
+	entry["timestamp"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = GetLogLevelString(level)
+	entry["layer"] = log.Layer
+	entry["message"] = log.Message
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(map[string]interface{}{
+			"timestamp": entry["timestamp"],
+			"level":     entry["level"],
+			"layer":     log.Layer,
+			"message":   log.Message,
+		})
+	}
+
+	out := logger.stdout
+	if level <= LogLevelError {
+		out = logger.stderr
+	}
+	out.Write(append(line, '\n'))
+}
@@ -4,12 +4,15 @@ import (
 	"fmt"
 	stdlog "log"
 	"os"
+	"sort"
+	"strings"
 )
 
 // LogStruct describes the shape of a new log
 type LogStruct struct {
 	Layer   string
 	Message string
+	Fields  map[string]interface{}
 }
 
 // NewLog creates a new LogStruct
@@ -17,10 +20,44 @@ func NewLog(layer string, message string) LogStruct {
 	return LogStruct{Layer: layer, Message: message}
 }
 
+// WithField returns a copy of the log with an additional key/value field such as user_id or resource_id
+func (log LogStruct) WithField(key string, value interface{}) LogStruct {
+	return log.WithFields(map[string]interface{}{key: value})
+}
+
+// WithFields returns a copy of the log with additional key/value fields
+func (log LogStruct) WithFields(fields map[string]interface{}) LogStruct {
+	merged := make(map[string]interface{}, len(log.Fields)+len(fields))
+	for k, v := range log.Fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	log.Fields = merged
+	return log
+}
+
 // Print determines how a log should be printed and sets the timestamp
 func (log *LogStruct) Print(logLevel int) string {
 	levelStr := GetLogLevelString(logLevel)
-	return fmt.Sprintf("[%s] (%s) %s", levelStr, log.Layer, log.Message)
+	line := fmt.Sprintf("[%s] (%s) %s", levelStr, log.Layer, log.Message)
+
+	keys := make([]string, 0, len(log.Fields))
+	for k := range log.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, fmt.Sprintf("%s=%v", k, log.Fields[k]))
+	}
+	if len(fields) > 0 {
+		line += " " + strings.Join(fields, " ")
+	}
+
+	return line
 }
 
 // Logger describes the methods available on a logger
@@ -68,6 +105,37 @@ func GetLogLevelString(level int) string {
 	return "UNKNOWN"
 }
 
+// ParseLogLevel converts a level name such as "info" or "WARN" into its iota
+func ParseLogLevel(level string) (int, error) {
+	for l := LogLevelFatal; l <= LogLevelVerbose; l++ {
+		if strings.EqualFold(level, GetLogLevelString(l)) {
+			return l, nil
+		}
+	}
+	if strings.EqualFold(level, "warning") {
+		return LogLevelWarning, nil
+	}
+	return LogLevelInfo, fmt.Errorf("unknown log level %s", level)
+}
+
+const (
+	// FormatText prints logs as human readable lines
+	FormatText = "text"
+	// FormatJSON prints logs as one JSON object per line
+	FormatJSON = "json"
+)
+
+// New creates a Logger printing in the given format, either text or json
+func New(format string, level int) (Logger, error) {
+	switch format {
+	case "", FormatText:
+		return NewStdLogger(level), nil
+	case FormatJSON:
+		return NewJSONLogger(level), nil
+	}
+	return nil, fmt.Errorf("unknown log format %s", format)
+}
+
 // StdLogger implements Logger using the standard library
 type StdLogger struct {
 	level   int
@@ -114,7 +182,6 @@ func (logger *StdLogger) Error(log LogStruct) {
 
 // Warning prints fatal logs
 func (logger *StdLogger) Warning(log LogStruct) {
-	println("warning called msg: %s. level: %s", log.Message, GetLogLevelString(LogLevelWarning))
 	if logger.level >= LogLevelWarning {
 		logger.warning.Println(log.Print(LogLevelWarning))
 	}
@@ -0,0 +1,63 @@
+package logs
+
+import "sync"
+
+// SwitchLogger is a Logger which forwards to another Logger that can be replaced while running,
+// so the format and level can follow changes to the config file
+type SwitchLogger struct {
+	mu     sync.RWMutex
+	logger Logger
+}
+
+// NewSwitchLogger creates a new SwitchLogger forwarding to logger
+func NewSwitchLogger(logger Logger) *SwitchLogger {
+	return &SwitchLogger{logger: logger}
+}
+
+// Swap replaces the Logger being forwarded to
+func (s *SwitchLogger) Swap(logger Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger = logger
+}
+
+func (s *SwitchLogger) current() Logger {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.logger
+}
+
+// SetLevel updates the level of the current logger
+func (s *SwitchLogger) SetLevel(level int) {
+	s.current().SetLevel(level)
+}
+
+// Fatal prints fatal logs
+func (s *SwitchLogger) Fatal(log LogStruct) {
+	s.current().Fatal(log)
+}
+
+// Error prints error logs
+func (s *SwitchLogger) Error(log LogStruct) {
+	s.current().Error(log)
+}
+
+// Warning prints warning logs
+func (s *SwitchLogger) Warning(log LogStruct) {
+	s.current().Warning(log)
+}
+
+// Info prints info logs
+func (s *SwitchLogger) Info(log LogStruct) {
+	s.current().Info(log)
+}
+
+// Debug prints debug logs
+func (s *SwitchLogger) Debug(log LogStruct) {
+	s.current().Debug(log)
+}
+
+// Verbose prints verbose logs
+func (s *SwitchLogger) Verbose(log LogStruct) {
+	s.current().Verbose(log)
+}
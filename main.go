@@ -1,116 +1,280 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/danielpadmore/cloudygo-service/auth"
 	"github.com/danielpadmore/cloudygo-service/config"
 	"github.com/danielpadmore/cloudygo-service/data"
 	"github.com/danielpadmore/cloudygo-service/handlers"
 	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/metrics"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/danielpadmore/cloudygo-service/provisioner"
+	"github.com/danielpadmore/cloudygo-service/ratelimit"
+	"github.com/danielpadmore/cloudygo-service/reaper"
+	"github.com/danielpadmore/cloudygo-service/registry"
 	"github.com/danielpadmore/cloudygo-service/validation"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
 	"github.com/nicholasjackson/env"
 )
 
 // TODO - consistent logs
-// TODO - tests
 
 // Config contains configuration data for the application
 type Config struct {
+	LogFormat    string `json:"log_format"`
+	LogLevel     string `json:"log_level"`
+	DbBackend    string `json:"db_backend"`
 	DbConnection string `json:"db_connection"`
 	BindAddress  string `json:"bind_address"`
+
+	MetricsAddress string `json:"metrics_address"`
+
+	ReadTimeout     config.Duration `json:"read_timeout"`
+	WriteTimeout    config.Duration `json:"write_timeout"`
+	IdleTimeout     config.Duration `json:"idle_timeout"`
+	ShutdownTimeout config.Duration `json:"shutdown_timeout"`
+
+	JWT            auth.Config               `json:"jwt"`
+	Admins         []string                  `json:"admins"`
+	PasswordPolicy validation.PasswordPolicy `json:"password_policy"`
+	LoginLockout   auth.LockoutConfig        `json:"login_lockout"`
+	RateLimits     ratelimit.Config          `json:"rate_limits"`
+	IdempotencyTTL config.Duration           `json:"idempotency_ttl"`
+	Provisioning   provisioner.Config        `json:"provisioning"`
+	Trash          reaper.Config             `json:"trash"`
 }
 
-var conf *Config
+const (
+	defaultReadTimeout     = 10 * time.Second
+	defaultWriteTimeout    = 30 * time.Second
+	defaultIdleTimeout     = 120 * time.Second
+	defaultShutdownTimeout = 30 * time.Second
+)
+
+// conf holds the *Config last loaded from the config file. Each load stores a new Config which is never changed,
+// so it can be read from any goroutine.
+var conf atomic.Value
+
+var appMetrics = metrics.New()
 
 var configFile = env.String("CONFIG_FILE", false, "./conf.json", "Path to JSON encoded config file")
+var dbBackend = env.String("DB_BACKEND", false, "", "Database backend, either postgres or memory. Overrides db_backend in the config file")
 
-func newLog(message string, a ...interface{}) logs.LogStruct {
-	return logs.NewLog("SETUP", fmt.Sprintf(message, a...))
+func newLog(ctx context.Context, message string, a ...interface{}) logs.LogStruct {
+	return logs.NewLog("SETUP", fmt.Sprintf(message, a...)).WithContext(ctx)
 }
 
 func main() {
 
-	logger := logs.NewStdLogger(logs.LogLevelInfo)
+	logger := logs.NewSwitchLogger(logs.NewStdLogger(logs.LogLevelInfo))
 
-	logger.Info(newLog("Initiating CloudyGo service..."))
+	logger.Info(newLog(context.Background(), "Initiating CloudyGo service..."))
 
 	err := env.Parse()
 	if err != nil {
-		logger.Fatal(newLog("Error parsing flags: %s", err.Error()))
+		logger.Fatal(newLog(context.Background(), "Error parsing flags: %s", err.Error()))
 		os.Exit(1)
 	}
 
-	conf = &Config{}
-
-	c, err := config.New(*configFile, conf, func() {
-		logger.Info(newLog("Config file updated"))
+	c, err := config.New(logger, *configFile, func() interface{} { return &Config{} }, func(loaded interface{}) {
+		conf.Store(loaded.(*Config))
+		configureLogger(logger)
+		logger.Info(newLog(context.Background(), "Config file loaded"))
 	})
 
 	if err != nil {
-		logger.Fatal(newLog("Unable to load config file: %s", err.Error()))
+		logger.Fatal(newLog(context.Background(), "Unable to load config file: %s", err.Error()))
 		os.Exit(1)
 	}
-	defer c.Close()
 
-	validator := validation.New(logger)
+	validator := validation.New(logger, func() validation.PasswordPolicy { return currentConfig().PasswordPolicy })
+
+	keys, err := auth.NewKeySet(currentConfig().JWT)
+	if err != nil {
+		logger.Fatal(newLog(context.Background(), "Unable to load JWT keys: %s", err.Error()))
+		os.Exit(1)
+	}
 
-	db, err := retryDbUntilReady(logger)
+	db, err := newConnection(logger)
 	if err != nil {
-		logger.Fatal(newLog("Timed out waiting for database connection"))
+		logger.Fatal(newLog(context.Background(), "Unable to connect to database: %s", err.Error()))
 		os.Exit(1)
 	}
 
+	appMetrics.RegisterResourceCounts(db)
+	db = metrics.NewConnection(db, appMetrics)
+
+	var metricsServer *http.Server
+	if currentConfig().MetricsAddress != "" {
+		metricsServer = newMetricsServer()
+		go serve(logger, metricsServer, "metrics server")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		provisioner.New(logger, db, func() provisioner.Config { return currentConfig().Provisioning }).Run(ctx)
+		workers.Done()
+	}()
+	go func() {
+		reaper.New(logger, db, trashConfig).Run(ctx)
+		workers.Done()
+	}()
+
 	router := mux.NewRouter()
-	registerRoutes(router, logger, validator, db)
+	registerRoutes(router, logger, validator, db, keys)
+	appMetrics.Instrument(router)
+
+	server := &http.Server{
+		Addr:         currentConfig().BindAddress,
+		Handler:      handlers.RequestID(router),
+		ReadTimeout:  durationOrDefault(currentConfig().ReadTimeout, defaultReadTimeout),
+		WriteTimeout: durationOrDefault(currentConfig().WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:  durationOrDefault(currentConfig().IdleTimeout, defaultIdleTimeout),
+	}
+	go serve(logger, server, "server")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-signals
 
-	logger.Info(newLog("Starting server on %s", conf.BindAddress))
-	err = http.ListenAndServe(conf.BindAddress, router)
+	logger.Info(newLog(context.Background(), "Received %s, shutting down", sig))
+
+	shutdownCtx, done := context.WithTimeout(context.Background(), durationOrDefault(currentConfig().ShutdownTimeout, defaultShutdownTimeout))
+	defer done()
+
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Error(newLog(context.Background(), "Unable to drain connections: %s", err.Error()))
+	}
+
+	if metricsServer != nil {
+		err = metricsServer.Shutdown(shutdownCtx)
+		if err != nil {
+			logger.Error(newLog(context.Background(), "Unable to stop metrics server: %s", err.Error()))
+		}
+	}
+
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		logger.Error(newLog(context.Background(), "Timed out waiting for provisioner and reaper to stop"))
+	}
+
+	err = db.Close()
 	if err != nil {
-		logger.Fatal(newLog("Unable to start server on %s. Error: %s", conf.BindAddress, err.Error()))
+		logger.Error(newLog(context.Background(), "Unable to close database connection: %s", err.Error()))
 	}
 
+	c.Close()
+
+	logger.Info(newLog(context.Background(), "Shutdown complete"))
 }
 
-func isAuthorizedMiddleware(next func(userID string, w http.ResponseWriter, r *http.Request)) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authToken := r.Header.Get("Authorization")
+// serve runs server until it is shut down, exiting if it cannot start
+func serve(logger logs.Logger, server *http.Server, name string) {
+	logger.Info(newLog(context.Background(), "Starting %s on %s", name, server.Addr))
 
-		if authToken == "" {
-			fmt.Println("No Authorization provided")
-			http.Error(w, "No Authorization provided", http.StatusUnauthorized)
-			return
-		}
+	err := server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		logger.Fatal(newLog(context.Background(), "Unable to start %s on %s. Error: %s", name, server.Addr, err.Error()))
+		os.Exit(1)
+	}
+}
+
+// durationOrDefault returns d, or def when d is not set in the config file
+func durationOrDefault(d config.Duration, def time.Duration) time.Duration {
+	if d.Duration <= 0 {
+		return def
+	}
+	return d.Duration
+}
+
+// currentConfig returns the config last loaded from the config file
+func currentConfig() *Config {
+	return conf.Load().(*Config)
+}
+
+// trashConfig returns the trash section of the current config
+func trashConfig() reaper.Config {
+	return currentConfig().Trash
+}
 
-		token, err := jwt.Parse(authToken, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				fmt.Println("Unable to parse JWT token", "path", r.URL.Path)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
-				return nil, nil
-			}
-			return []byte(handlers.JWTSecret), nil
-		})
+// configureLogger applies the log format and level from the config file
+func configureLogger(logger *logs.SwitchLogger) {
+	current := currentConfig()
 
+	level := logs.LogLevelInfo
+	if current.LogLevel != "" {
+		l, err := logs.ParseLogLevel(current.LogLevel)
 		if err != nil {
-			fmt.Println("Unauthorized", "error", err)
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+			logger.Warning(newLog(context.Background(), "Unable to set log level: %s", err.Error()))
 			return
 		}
+		level = l
+	}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userID := claims["user_id"].(string)
-			next(userID, w, r)
-			return
+	l, err := logs.New(current.LogFormat, level)
+	if err != nil {
+		logger.Warning(newLog(context.Background(), "Unable to set log format: %s", err.Error()))
+		return
+	}
+
+	logger.Swap(l)
+}
+
+// newMetricsServer creates a server exposing the Prometheus metrics on the metrics address, separately from the API
+func newMetricsServer() *http.Server {
+	serveMux := http.NewServeMux()
+	serveMux.Handle("/metrics", appMetrics.Handler())
+
+	return &http.Server{
+		Addr:         currentConfig().MetricsAddress,
+		Handler:      serveMux,
+		ReadTimeout:  durationOrDefault(currentConfig().ReadTimeout, defaultReadTimeout),
+		WriteTimeout: durationOrDefault(currentConfig().WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:  durationOrDefault(currentConfig().IdleTimeout, defaultIdleTimeout),
+	}
+}
+
+func newConnection(logger logs.Logger) (data.Connection, error) {
+	backend := currentConfig().DbBackend
+	if *dbBackend != "" {
+		backend = *dbBackend
+	}
+
+	switch backend {
+	case "", "postgres":
+		db, err := retryDbUntilReady(logger)
+		if err != nil {
+			return nil, fmt.Errorf("timed out waiting for database connection: %s", err)
 		}
+		return db, nil
+	case "memory":
+		logger.Warning(newLog(context.Background(), "Using in-memory database, data will be lost on shutdown"))
+		return data.NewMemory(logger), nil
+	}
 
-		fmt.Println("Invalid token", "error", err)
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
-	})
+	return nil, fmt.Errorf("unknown database backend %s", backend)
 }
 
 func retryDbUntilReady(logger logs.Logger) (data.Connection, error) {
@@ -119,14 +283,13 @@ func retryDbUntilReady(logger logs.Logger) (data.Connection, error) {
 	mt := 60 * time.Second
 
 	for {
-		db, err := data.New(logger, conf.DbConnection)
+		db, err := data.New(logger, currentConfig().DbConnection)
 		if err == nil {
-			logger.Info(newLog(fmt.Sprintf("Successfully connected to database in %fs", time.Now().Sub(st).Seconds())))
+			logger.Info(newLog(context.Background(), fmt.Sprintf("Successfully connected to database in %fs", time.Now().Sub(st).Seconds())))
 			return db, nil
 		}
 
-		logger.Warning(newLog(fmt.Sprintf("Unable to connect to the database. Error: %s", err.Error())))
-		fmt.Println("Unable to connect to the database", "error", err)
+		logger.Warning(newLog(context.Background(), fmt.Sprintf("Unable to connect to the database. Error: %s", err.Error())))
 
 		if time.Now().Sub(st) > mt {
 			return nil, err
@@ -136,52 +299,88 @@ func retryDbUntilReady(logger logs.Logger) (data.Connection, error) {
 	}
 }
 
-func registerRoutes(router *mux.Router, logger logs.Logger, validator validation.Validator, db data.Connection) {
+func registerRoutes(router *mux.Router, logger logs.Logger, validator validation.Validator, db data.Connection, keys *auth.KeySet) {
+
+	logger.Debug(newLog(context.Background(), "Registering routes"))
+
+	router.NotFoundHandler = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		problem.Write(rw, r, problem.NotFound, fmt.Sprintf("No route matches %s", r.URL.Path))
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		problem.Write(rw, r, problem.MethodNotAllowed, fmt.Sprintf("Method %s is not allowed on %s", r.Method, r.URL.Path))
+	})
+
+	isAuthorized := newAuthMiddleware(logger, keys, db)
 
-	logger.Debug(newLog("Registering routes"))
+	limits := ratelimit.NewMemory()
+	limitAuth := newIPRateLimitMiddleware(logger, limits, "auth", func() ratelimit.Limit { return currentConfig().RateLimits.Auth })
+	limitPublic := newIPRateLimitMiddleware(logger, limits, "default", func() ratelimit.Limit { return currentConfig().RateLimits.Default })
+	limitResources := newUserRateLimitMiddleware(logger, limits, "resources", func() ratelimit.Limit { return currentConfig().RateLimits.Resources })
+	limitDefault := newUserRateLimitMiddleware(logger, limits, "default", func() ratelimit.Limit { return currentConfig().RateLimits.Default })
 
 	healthHandler := handlers.NewHealth(logger, db)
 	router.Handle("/health", healthHandler).Methods("GET")
 
-	resourceHandler := handlers.NewResource(logger, db)
-	router.Handle("/resources", resourceHandler).Methods("GET")
-
-	userHandler := handlers.NewUser(logger, db)
-	router.HandleFunc("/register", userHandler.Register).Methods("POST")
-	router.HandleFunc("/signin", userHandler.SignIn).Methods("POST")
-
-	lambdaHandler := handlers.NewLambda(logger, validator, db)
-	lambdaRouter := router.PathPrefix("/lambdas").Subrouter()
-	lambdaRouter.Handle("", isAuthorizedMiddleware(lambdaHandler.CreateLambda)).Methods("POST")
-	lambdaRouter.Handle("", isAuthorizedMiddleware(lambdaHandler.GetLambdas)).Methods("GET")
-	lambdaRouter.Handle("/{id}", isAuthorizedMiddleware(lambdaHandler.GetLambda)).Methods("GET")
-	lambdaRouter.Handle("/{id}", isAuthorizedMiddleware(lambdaHandler.UpdateLambda)).Methods("PUT")
-	lambdaRouter.Handle("/{id}", isAuthorizedMiddleware(lambdaHandler.DeleteLambda)).Methods("DELETE")
-
-	vmHandler := handlers.NewVirtualMachine(logger, db)
-	vmRouter := router.PathPrefix("/virtual-machines").Subrouter()
-	vmRouter.Handle("", isAuthorizedMiddleware(vmHandler.CreateVirtualMachine)).Methods("POST")
-	vmRouter.Handle("", isAuthorizedMiddleware(vmHandler.GetVirtualMachines)).Methods("GET")
-	vmRouter.Handle("/{id}", isAuthorizedMiddleware(vmHandler.GetVirtualMachine)).Methods("GET")
-	vmRouter.Handle("/{id}", isAuthorizedMiddleware(vmHandler.UpdateVirtualMachine)).Methods("PUT")
-	vmRouter.Handle("/{id}", isAuthorizedMiddleware(vmHandler.DeleteVirtualMachine)).Methods("DELETE")
-
-	sqldbHandler := handlers.NewSQLDatabase(logger, db)
-	sqldbRouter := router.PathPrefix("/sql-databases").Subrouter()
-	sqldbRouter.Handle("", isAuthorizedMiddleware(sqldbHandler.CreateSQLDatabase)).Methods("POST")
-	sqldbRouter.Handle("", isAuthorizedMiddleware(sqldbHandler.GetSQLDatabases)).Methods("GET")
-	sqldbRouter.Handle("/{id}", isAuthorizedMiddleware(sqldbHandler.GetSQLDatabase)).Methods("GET")
-	sqldbRouter.Handle("/{id}", isAuthorizedMiddleware(sqldbHandler.UpdateSQLDatabase)).Methods("PUT")
-	sqldbRouter.Handle("/{id}", isAuthorizedMiddleware(sqldbHandler.DeleteSQLDatabase)).Methods("DELETE")
-
-	nosqldbHandler := handlers.NewNoSQLDatabase(logger, db)
-	nosqldbRouter := router.PathPrefix("/nosql-databases").Subrouter()
-	nosqldbRouter.Handle("", isAuthorizedMiddleware(nosqldbHandler.CreateNoSQLDatabase)).Methods("POST")
-	nosqldbRouter.Handle("", isAuthorizedMiddleware(nosqldbHandler.GetNoSQLDatabases)).Methods("GET")
-	nosqldbRouter.Handle("/{id}", isAuthorizedMiddleware(nosqldbHandler.GetNoSQLDatabase)).Methods("GET")
-	nosqldbRouter.Handle("/{id}", isAuthorizedMiddleware(nosqldbHandler.UpdateNoSQLDatabase)).Methods("PUT")
-	nosqldbRouter.Handle("/{id}", isAuthorizedMiddleware(nosqldbHandler.DeleteNoSQLDatabase)).Methods("DELETE")
-
-	logger.Debug(newLog("Routes registered"))
+	resourceHandler := handlers.NewResource(logger)
+	router.Handle("/resources", limitPublic(resourceHandler)).Methods("GET")
+
+	idempotency := handlers.NewIdempotency(logger, db, currentConfig().IdempotencyTTL.Duration)
+
+	jwksHandler := handlers.NewJWKS(logger, keys)
+	router.Handle("/.well-known/jwks.json", limitPublic(jwksHandler)).Methods("GET")
+
+	userHandler := handlers.NewUser(logger, validator, db, keys, func() auth.LockoutConfig { return currentConfig().LoginLockout })
+	router.Handle("/register", limitAuth(http.HandlerFunc(userHandler.Register))).Methods("POST")
+	router.Handle("/signin", limitAuth(http.HandlerFunc(userHandler.SignIn))).Methods("POST")
+	router.Handle("/token/refresh", limitAuth(http.HandlerFunc(userHandler.Refresh))).Methods("POST")
+	router.Handle("/signout", isAuthorized("", limitDefault(userHandler.SignOut))).Methods("POST")
+	router.Handle("/password", isAuthorized("", limitDefault(userHandler.ChangePassword))).Methods("PUT")
+
+	apiKeyHandler := handlers.NewAPIKey(logger, validator, db)
+	apiKeyRouter := router.PathPrefix("/api-keys").Subrouter()
+	apiKeyRouter.Handle("", isAuthorized("api_keys:write", limitDefault(apiKeyHandler.CreateAPIKey))).Methods("POST")
+	apiKeyRouter.Handle("", isAuthorized("api_keys:read", limitDefault(apiKeyHandler.GetAPIKeys))).Methods("GET")
+	apiKeyRouter.Handle("/{id}", isAuthorized("api_keys:write", limitDefault(apiKeyHandler.DeleteAPIKey))).Methods("DELETE")
+
+	quotaHandler := handlers.NewQuota(logger, db)
+	router.Handle("/quotas", isAuthorized("quotas:read", limitDefault(quotaHandler.GetQuotas))).Methods("GET")
+
+	orgHandler := handlers.NewOrganization(logger, validator, db)
+	orgRouter := router.PathPrefix("/organizations").Subrouter()
+	orgRouter.Handle("", isAuthorized("organizations:write", limitDefault(orgHandler.CreateOrganization))).Methods("POST")
+	orgRouter.Handle("", isAuthorized("organizations:read", limitDefault(orgHandler.GetOrganizations))).Methods("GET")
+	orgRouter.Handle("/{id}", isAuthorized("organizations:read", limitDefault(orgHandler.GetOrganization))).Methods("GET")
+	orgRouter.Handle("/{id}", isAuthorized("organizations:write", limitDefault(orgHandler.DeleteOrganization))).Methods("DELETE")
+	orgRouter.Handle("/{id}/members", isAuthorized("organizations:write", limitDefault(orgHandler.AddMember))).Methods("POST")
+	orgRouter.Handle("/{id}/members/{user_id}", isAuthorized("organizations:write", limitDefault(orgHandler.UpdateMember))).Methods("PUT")
+	orgRouter.Handle("/{id}/members/{user_id}", isAuthorized("organizations:write", limitDefault(orgHandler.RemoveMember))).Methods("DELETE")
+
+	auditHandler := handlers.NewAudit(logger, db)
+	router.Handle("/audit", isAuthorized("audit:read", limitDefault(auditHandler.GetAuditEvents))).Methods("GET")
+
+	isAdmin := newAdminMiddleware(logger, db)
+
+	adminHandler := handlers.NewAdmin(logger, db)
+	adminRouter := router.PathPrefix("/admin/users").Subrouter()
+	adminRouter.Handle("", isAuthorized("users:read", limitDefault(isAdmin(adminHandler.GetUsers)))).Methods("GET")
+	adminRouter.Handle("/{id}", isAuthorized("users:read", limitDefault(isAdmin(adminHandler.GetUser)))).Methods("GET")
+	adminRouter.Handle("/{id}", isAuthorized("users:write", limitDefault(isAdmin(adminHandler.DeleteUser)))).Methods("DELETE")
+	adminRouter.Handle("/{id}/disable", isAuthorized("users:write", limitDefault(isAdmin(adminHandler.DisableUser)))).Methods("POST")
+	adminRouter.Handle("/{id}/enable", isAuthorized("users:write", limitDefault(isAdmin(adminHandler.EnableUser)))).Methods("POST")
+	adminRouter.Handle("/{id}/reset-password", isAuthorized("users:write", limitDefault(isAdmin(adminHandler.ResetPassword)))).Methods("POST")
+
+	for _, t := range registry.Types() {
+		resourceHandler := handlers.NewCloudResource(logger, validator, db, trashConfig, t)
+		resourceRouter := router.PathPrefix(t.Path).Subrouter()
+		resourceRouter.Handle("", isAuthorized(t.Scope("write"), limitResources(idempotency.Handle(resourceHandler.CreateResource)))).Methods("POST")
+		resourceRouter.Handle("", isAuthorized(t.Scope("read"), limitResources(resourceHandler.GetResources))).Methods("GET")
+		resourceRouter.Handle("/{id}", isAuthorized(t.Scope("read"), limitResources(resourceHandler.GetResource))).Methods("GET")
+		resourceRouter.Handle("/{id}", isAuthorized(t.Scope("write"), limitResources(resourceHandler.UpdateResource))).Methods("PUT")
+		resourceRouter.Handle("/{id}", isAuthorized(t.Scope("write"), limitResources(resourceHandler.PatchResource))).Methods("PATCH")
+		resourceRouter.Handle("/{id}", isAuthorized(t.Scope("write"), limitResources(resourceHandler.DeleteResource))).Methods("DELETE")
+		resourceRouter.Handle("/{id}/restore", isAuthorized(t.Scope("write"), limitResources(resourceHandler.RestoreResource))).Methods("POST")
+	}
+
+	logger.Debug(newLog(context.Background(), "Routes registered"))
 
 }
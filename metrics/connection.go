@@ -0,0 +1,329 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/model"
+)
+
+// Connection wraps a data.Connection, timing every method call
+type Connection struct {
+	next    data.Connection
+	metrics *Metrics
+}
+
+// NewConnection wraps a data.Connection so its method durations are recorded
+func NewConnection(next data.Connection, metrics *Metrics) data.Connection {
+	return &Connection{next, metrics}
+}
+
+func (c *Connection) observe(method string, start time.Time) {
+	c.metrics.queryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// IsConnected times data.Connection.IsConnected
+func (c *Connection) IsConnected(ctx context.Context) (bool, error) {
+	defer c.observe("IsConnected", time.Now())
+	return c.next.IsConnected(ctx)
+}
+
+// Close closes the wrapped data.Connection
+func (c *Connection) Close() error {
+	return c.next.Close()
+}
+
+// CreateUser times data.Connection.CreateUser
+func (c *Connection) CreateUser(ctx context.Context, username string, password string) (model.User, error) {
+	defer c.observe("CreateUser", time.Now())
+	return c.next.CreateUser(ctx, username, password)
+}
+
+// AuthenticateUser times data.Connection.AuthenticateUser
+func (c *Connection) AuthenticateUser(ctx context.Context, username string, password string) (model.User, error) {
+	defer c.observe("AuthenticateUser", time.Now())
+	return c.next.AuthenticateUser(ctx, username, password)
+}
+
+// GetUser times data.Connection.GetUser
+func (c *Connection) GetUser(ctx context.Context, userID string) (model.User, error) {
+	defer c.observe("GetUser", time.Now())
+	return c.next.GetUser(ctx, userID)
+}
+
+// GetUserByUsername times data.Connection.GetUserByUsername
+func (c *Connection) GetUserByUsername(ctx context.Context, username string) (model.User, error) {
+	defer c.observe("GetUserByUsername", time.Now())
+	return c.next.GetUserByUsername(ctx, username)
+}
+
+// GetUsers times data.Connection.GetUsers
+func (c *Connection) GetUsers(ctx context.Context, opts model.ListOptions) (model.Users, string, error) {
+	defer c.observe("GetUsers", time.Now())
+	return c.next.GetUsers(ctx, opts)
+}
+
+// SetUserDisabled times data.Connection.SetUserDisabled
+func (c *Connection) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	defer c.observe("SetUserDisabled", time.Now())
+	return c.next.SetUserDisabled(ctx, userID, disabled)
+}
+
+// DeleteUser times data.Connection.DeleteUser
+func (c *Connection) DeleteUser(ctx context.Context, userID string) error {
+	defer c.observe("DeleteUser", time.Now())
+	return c.next.DeleteUser(ctx, userID)
+}
+
+// ResetPassword times data.Connection.ResetPassword
+func (c *Connection) ResetPassword(ctx context.Context, userID string, password string) error {
+	defer c.observe("ResetPassword", time.Now())
+	return c.next.ResetPassword(ctx, userID, password)
+}
+
+// ChangePassword times data.Connection.ChangePassword
+func (c *Connection) ChangePassword(ctx context.Context, userID string, password string) error {
+	defer c.observe("ChangePassword", time.Now())
+	return c.next.ChangePassword(ctx, userID, password)
+}
+
+// RecordLoginFailure times data.Connection.RecordLoginFailure
+func (c *Connection) RecordLoginFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	defer c.observe("RecordLoginFailure", time.Now())
+	return c.next.RecordLoginFailure(ctx, key, window)
+}
+
+// LockLogin times data.Connection.LockLogin
+func (c *Connection) LockLogin(ctx context.Context, key string, lockout time.Duration) error {
+	defer c.observe("LockLogin", time.Now())
+	return c.next.LockLogin(ctx, key, lockout)
+}
+
+// GetLoginLockout times data.Connection.GetLoginLockout
+func (c *Connection) GetLoginLockout(ctx context.Context, keys []string) (time.Duration, error) {
+	defer c.observe("GetLoginLockout", time.Now())
+	return c.next.GetLoginLockout(ctx, keys)
+}
+
+// ClearLoginFailures times data.Connection.ClearLoginFailures
+func (c *Connection) ClearLoginFailures(ctx context.Context, key string) error {
+	defer c.observe("ClearLoginFailures", time.Now())
+	return c.next.ClearLoginFailures(ctx, key)
+}
+
+// CreateRefreshToken times data.Connection.CreateRefreshToken
+func (c *Connection) CreateRefreshToken(ctx context.Context, userID string, tokenHash string, scopes []string, ttl time.Duration) error {
+	defer c.observe("CreateRefreshToken", time.Now())
+	return c.next.CreateRefreshToken(ctx, userID, tokenHash, scopes, ttl)
+}
+
+// RotateRefreshToken times data.Connection.RotateRefreshToken
+func (c *Connection) RotateRefreshToken(ctx context.Context, tokenHash string, newTokenHash string, ttl time.Duration) (model.RefreshToken, error) {
+	defer c.observe("RotateRefreshToken", time.Now())
+	return c.next.RotateRefreshToken(ctx, tokenHash, newTokenHash, ttl)
+}
+
+// RevokeRefreshToken times data.Connection.RevokeRefreshToken
+func (c *Connection) RevokeRefreshToken(ctx context.Context, userID string, tokenHash string) error {
+	defer c.observe("RevokeRefreshToken", time.Now())
+	return c.next.RevokeRefreshToken(ctx, userID, tokenHash)
+}
+
+// RevokeToken times data.Connection.RevokeToken
+func (c *Connection) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	defer c.observe("RevokeToken", time.Now())
+	return c.next.RevokeToken(ctx, jti, expiresAt)
+}
+
+// IsTokenRevoked times data.Connection.IsTokenRevoked
+func (c *Connection) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	defer c.observe("IsTokenRevoked", time.Now())
+	return c.next.IsTokenRevoked(ctx, jti)
+}
+
+// CreateAPIKey times data.Connection.CreateAPIKey
+func (c *Connection) CreateAPIKey(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	defer c.observe("CreateAPIKey", time.Now())
+	return c.next.CreateAPIKey(ctx, key)
+}
+
+// GetAPIKeys times data.Connection.GetAPIKeys
+func (c *Connection) GetAPIKeys(ctx context.Context, userID string) (model.APIKeys, error) {
+	defer c.observe("GetAPIKeys", time.Now())
+	return c.next.GetAPIKeys(ctx, userID)
+}
+
+// RevokeAPIKey times data.Connection.RevokeAPIKey
+func (c *Connection) RevokeAPIKey(ctx context.Context, userID string, keyID string) error {
+	defer c.observe("RevokeAPIKey", time.Now())
+	return c.next.RevokeAPIKey(ctx, userID, keyID)
+}
+
+// AuthenticateAPIKey times data.Connection.AuthenticateAPIKey
+func (c *Connection) AuthenticateAPIKey(ctx context.Context, keyHash string) (model.APIKey, error) {
+	defer c.observe("AuthenticateAPIKey", time.Now())
+	return c.next.AuthenticateAPIKey(ctx, keyHash)
+}
+
+// CreateOrganization times data.Connection.CreateOrganization
+func (c *Connection) CreateOrganization(ctx context.Context, userID string, name string) (model.Organization, error) {
+	defer c.observe("CreateOrganization", time.Now())
+	return c.next.CreateOrganization(ctx, userID, name)
+}
+
+// GetOrganizations times data.Connection.GetOrganizations
+func (c *Connection) GetOrganizations(ctx context.Context, userID string) (model.Organizations, error) {
+	defer c.observe("GetOrganizations", time.Now())
+	return c.next.GetOrganizations(ctx, userID)
+}
+
+// GetOrganization times data.Connection.GetOrganization
+func (c *Connection) GetOrganization(ctx context.Context, userID string, orgID string) (model.Organization, error) {
+	defer c.observe("GetOrganization", time.Now())
+	return c.next.GetOrganization(ctx, userID, orgID)
+}
+
+// DeleteOrganization times data.Connection.DeleteOrganization
+func (c *Connection) DeleteOrganization(ctx context.Context, orgID string) error {
+	defer c.observe("DeleteOrganization", time.Now())
+	return c.next.DeleteOrganization(ctx, orgID)
+}
+
+// GetMembers times data.Connection.GetMembers
+func (c *Connection) GetMembers(ctx context.Context, orgID string) (model.Memberships, error) {
+	defer c.observe("GetMembers", time.Now())
+	return c.next.GetMembers(ctx, orgID)
+}
+
+// GetMembership times data.Connection.GetMembership
+func (c *Connection) GetMembership(ctx context.Context, orgID string, userID string) (model.Membership, error) {
+	defer c.observe("GetMembership", time.Now())
+	return c.next.GetMembership(ctx, orgID, userID)
+}
+
+// AddMember times data.Connection.AddMember
+func (c *Connection) AddMember(ctx context.Context, member model.Membership) (model.Membership, error) {
+	defer c.observe("AddMember", time.Now())
+	return c.next.AddMember(ctx, member)
+}
+
+// UpdateMember times data.Connection.UpdateMember
+func (c *Connection) UpdateMember(ctx context.Context, orgID string, userID string, role string) (model.Membership, error) {
+	defer c.observe("UpdateMember", time.Now())
+	return c.next.UpdateMember(ctx, orgID, userID, role)
+}
+
+// RemoveMember times data.Connection.RemoveMember
+func (c *Connection) RemoveMember(ctx context.Context, orgID string, userID string) error {
+	defer c.observe("RemoveMember", time.Now())
+	return c.next.RemoveMember(ctx, orgID, userID)
+}
+
+// CreateResource times data.Connection.CreateResource
+func (c *Connection) CreateResource(ctx context.Context, userID string, resourceType string, row interface{}) (interface{}, error) {
+	defer c.observe("CreateResource", time.Now())
+	return c.next.CreateResource(ctx, userID, resourceType, row)
+}
+
+// GetResource times data.Connection.GetResource
+func (c *Connection) GetResource(ctx context.Context, userID string, resourceType string, id string) (interface{}, error) {
+	defer c.observe("GetResource", time.Now())
+	return c.next.GetResource(ctx, userID, resourceType, id)
+}
+
+// GetResources times data.Connection.GetResources
+func (c *Connection) GetResources(ctx context.Context, userID string, resourceType string, opts model.ListOptions) ([]interface{}, string, error) {
+	defer c.observe("GetResources", time.Now())
+	return c.next.GetResources(ctx, userID, resourceType, opts)
+}
+
+// UpdateResource times data.Connection.UpdateResource
+func (c *Connection) UpdateResource(ctx context.Context, userID string, resourceType string, id string, row interface{}, version *int) (interface{}, error) {
+	defer c.observe("UpdateResource", time.Now())
+	return c.next.UpdateResource(ctx, userID, resourceType, id, row, version)
+}
+
+// PatchResource times data.Connection.PatchResource
+func (c *Connection) PatchResource(ctx context.Context, userID string, resourceType string, id string, row interface{}, columns []string, version *int) (interface{}, error) {
+	defer c.observe("PatchResource", time.Now())
+	return c.next.PatchResource(ctx, userID, resourceType, id, row, columns, version)
+}
+
+// DeleteResource times data.Connection.DeleteResource
+func (c *Connection) DeleteResource(ctx context.Context, userID string, resourceType string, id string, version *int) error {
+	defer c.observe("DeleteResource", time.Now())
+	return c.next.DeleteResource(ctx, userID, resourceType, id, version)
+}
+
+// GetResourcesByStatus times data.Connection.GetResourcesByStatus
+func (c *Connection) GetResourcesByStatus(ctx context.Context, status string, age time.Duration) ([]model.ResourceRef, error) {
+	defer c.observe("GetResourcesByStatus", time.Now())
+	return c.next.GetResourcesByStatus(ctx, status, age)
+}
+
+// TransitionResource times data.Connection.TransitionResource
+func (c *Connection) TransitionResource(ctx context.Context, ref model.ResourceRef, status string) error {
+	defer c.observe("TransitionResource", time.Now())
+	return c.next.TransitionResource(ctx, ref, status)
+}
+
+// CountResources times data.Connection.CountResources
+func (c *Connection) CountResources(ctx context.Context) ([]model.ResourceCount, error) {
+	defer c.observe("CountResources", time.Now())
+	return c.next.CountResources(ctx)
+}
+
+// RestoreResource times data.Connection.RestoreResource
+func (c *Connection) RestoreResource(ctx context.Context, userID string, resourceType string, id string, retention time.Duration) error {
+	defer c.observe("RestoreResource", time.Now())
+	return c.next.RestoreResource(ctx, userID, resourceType, id, retention)
+}
+
+// PurgeResources times data.Connection.PurgeResources
+func (c *Connection) PurgeResources(ctx context.Context, retention time.Duration) ([]model.ResourceRef, error) {
+	defer c.observe("PurgeResources", time.Now())
+	return c.next.PurgeResources(ctx, retention)
+}
+
+// GetQuotaUsage times data.Connection.GetQuotaUsage
+func (c *Connection) GetQuotaUsage(ctx context.Context, userID string) (model.QuotaUsages, error) {
+	defer c.observe("GetQuotaUsage", time.Now())
+	return c.next.GetQuotaUsage(ctx, userID)
+}
+
+// CreateIdempotencyKey times data.Connection.CreateIdempotencyKey
+func (c *Connection) CreateIdempotencyKey(ctx context.Context, userID string, key string, requestHash string, ttl time.Duration) error {
+	defer c.observe("CreateIdempotencyKey", time.Now())
+	return c.next.CreateIdempotencyKey(ctx, userID, key, requestHash, ttl)
+}
+
+// GetIdempotencyKey times data.Connection.GetIdempotencyKey
+func (c *Connection) GetIdempotencyKey(ctx context.Context, userID string, key string) (model.IdempotencyRecord, error) {
+	defer c.observe("GetIdempotencyKey", time.Now())
+	return c.next.GetIdempotencyKey(ctx, userID, key)
+}
+
+// CompleteIdempotencyKey times data.Connection.CompleteIdempotencyKey
+func (c *Connection) CompleteIdempotencyKey(ctx context.Context, record model.IdempotencyRecord) error {
+	defer c.observe("CompleteIdempotencyKey", time.Now())
+	return c.next.CompleteIdempotencyKey(ctx, record)
+}
+
+// DeleteIdempotencyKey times data.Connection.DeleteIdempotencyKey
+func (c *Connection) DeleteIdempotencyKey(ctx context.Context, userID string, key string) error {
+	defer c.observe("DeleteIdempotencyKey", time.Now())
+	return c.next.DeleteIdempotencyKey(ctx, userID, key)
+}
+
+// CreateAuditEvent times data.Connection.CreateAuditEvent
+func (c *Connection) CreateAuditEvent(ctx context.Context, event model.AuditEvent) error {
+	defer c.observe("CreateAuditEvent", time.Now())
+	return c.next.CreateAuditEvent(ctx, event)
+}
+
+// GetAuditEvents times data.Connection.GetAuditEvents
+func (c *Connection) GetAuditEvents(ctx context.Context, userID string, opts model.ListOptions) (model.AuditEvents, string, error) {
+	defer c.observe("GetAuditEvents", time.Now())
+	return c.next.GetAuditEvents(ctx, userID, opts)
+}
@@ -0,0 +1,186 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name
+const namespace = "cloudygo"
+
+// countTimeout bounds how long a scrape waits for resource counts
+const countTimeout = 5 * time.Second
+
+// Metrics holds the collectors exported on the metrics endpoint
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	authAttempts    *prometheus.CounterVec
+	rateLimited     *prometheus.CounterVec
+}
+
+// New creates and registers the service metrics, along with Go runtime and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency, by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Duration of data.Connection calls, by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		authAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_attempts_total",
+			Help:      "Authorization checks on protected routes, by result.",
+		}, []string{"result"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limited_requests_total",
+			Help:      "Requests rejected for exceeding a rate limit, by route group.",
+		}, []string{"group"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		m.authAttempts,
+		m.rateLimited,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RegisterResourceCounts exports the number of live resources by type and status, counted on every scrape
+func (m *Metrics) RegisterResourceCounts(connection data.Connection) {
+	m.registry.MustRegister(&resourceCollector{
+		connection: connection,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "resources"),
+			"Live resources, by type and status.",
+			[]string{"type", "status"}, nil,
+		),
+	})
+}
+
+// AuthSucceeded counts a request which was authorized
+func (m *Metrics) AuthSucceeded() {
+	m.authAttempts.WithLabelValues("success").Inc()
+}
+
+// AuthForbidden counts a request whose credentials were valid but lacked the scope it needed
+func (m *Metrics) AuthForbidden() {
+	m.authAttempts.WithLabelValues("forbidden").Inc()
+}
+
+// AuthFailed counts a request which was rejected as unauthorized
+func (m *Metrics) AuthFailed() {
+	m.authAttempts.WithLabelValues("failure").Inc()
+}
+
+// RateLimited counts a request which was rejected for exceeding the rate limit of its route group
+func (m *Metrics) RateLimited(group string) {
+	m.rateLimited.WithLabelValues(group).Inc()
+}
+
+// Instrument records the requests served by router, including those which match no route and are counted as
+// unmatched. It must be called once the router's NotFoundHandler and MethodNotAllowedHandler are set, as mux only runs
+// middleware for matched routes.
+func (m *Metrics) Instrument(router *mux.Router) {
+	router.Use(m.Middleware)
+
+	notFound := router.NotFoundHandler
+	if notFound == nil {
+		notFound = http.NotFoundHandler()
+	}
+	router.NotFoundHandler = m.Middleware(notFound)
+
+	if router.MethodNotAllowedHandler != nil {
+		router.MethodNotAllowedHandler = m.Middleware(router.MethodNotAllowedHandler)
+	}
+}
+
+// Middleware records the count and latency of requests by their route template
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		status := strconv.Itoa(rec.status)
+		m.requests.WithLabelValues(route, r.Method, status).Inc()
+		m.requestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder keeps the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// resourceCollector counts resources through the data.Connection whenever metrics are scraped
+type resourceCollector struct {
+	connection data.Connection
+	desc       *prometheus.Desc
+}
+
+func (c *resourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *resourceCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	counts, err := c.connection.CountResources(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count.Count), count.Type, count.Status)
+	}
+}
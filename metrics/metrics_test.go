@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestInstrumentCountsUnmatchedRoutes(t *testing.T) {
+	m := New()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/lambdas/{id}", func(http.ResponseWriter, *http.Request) {}).Methods(http.MethodGet)
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	m.Instrument(router)
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/lambdas/abc"},
+		{http.MethodGet, "/unknown"},
+		{http.MethodDelete, "/lambdas/abc"},
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	rw := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	for _, want := range []string{
+		`cloudygo_http_requests_total{method="GET",route="/lambdas/{id}",status="200"} 1`,
+		`cloudygo_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`cloudygo_http_requests_total{method="DELETE",route="unmatched",status="405"} 1`,
+	} {
+		if !strings.Contains(rw.Body.String(), want) {
+			t.Errorf("metrics are missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/handlers"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/danielpadmore/cloudygo-service/problem"
+	"github.com/danielpadmore/cloudygo-service/ratelimit"
+)
+
+// apiKeyHeader is an alternative to sending an API key in the Authorization header
+const apiKeyHeader = "X-API-Key"
+
+// organizationHeader selects an organization the user belongs to, so resources are created in and listed from it
+const organizationHeader = "X-Organization-ID"
+
+// errUnauthorized marks credentials which were checked and rejected, rather than a failure while checking them
+var errUnauthorized = errors.New("Unauthorized")
+
+// errPasswordResetRequired marks users whose password was reset by an admin and who have not yet changed it
+var errPasswordResetRequired = errors.New("Password reset required")
+
+// passwordResetRoutes are the only routes users who must change their password can use
+var passwordResetRoutes = map[string]bool{"/password": true, "/signout": true}
+
+// revocationLeeway is how much earlier than a user's tokens were revoked a token can be issued and still be accepted.
+// It absorbs the rounding of issue and revocation times, so a token signed straight after a revocation, such as the
+// one returned by a password change, is never mistaken for one signed before it.
+const revocationLeeway = time.Millisecond
+
+// newAuthMiddleware returns middleware which authorizes requests with either a token verified by keys which
+// has not been revoked, or an active API key, passing the user they belong to on to the wrapped handler.
+// Credentials of disabled or deleted users are rejected, and users whose password was reset by an admin can only change
+// their password or sign out. Credentials must grant the scope a route declares, unless the scope is empty. Requests naming an organization
+// in the X-Organization-ID header are only passed on if the user belongs to it.
+func newAuthMiddleware(logger logs.Logger, keys *auth.KeySet, db data.Connection) func(scope string, next func(userID string, w http.ResponseWriter, r *http.Request)) http.Handler {
+	return func(scope string, next func(userID string, w http.ResponseWriter, r *http.Request)) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			credential := requestCredential(r)
+
+			if credential == "" {
+				logger.Info(newLog(r.Context(), "No Authorization provided for %s", r.URL.Path))
+				appMetrics.AuthFailed()
+				problem.Write(w, r, problem.Unauthorized, "No Authorization provided")
+				return
+			}
+
+			authenticate := authenticateToken
+			if auth.IsAPIKey(credential) {
+				authenticate = authenticateAPIKey
+			}
+
+			userID, ctx, err := authenticate(r.Context(), keys, db, credential)
+			if err == nil {
+				err = checkUser(ctx, db, userID)
+			}
+			if errors.Is(err, errUnauthorized) {
+				logger.Info(newLog(r.Context(), "Unauthorized request for %s: %s", r.URL.Path, err.Error()))
+				appMetrics.AuthFailed()
+				problem.Write(w, r, problem.Unauthorized, "Unauthorized")
+				return
+			}
+			if errors.Is(err, errPasswordResetRequired) {
+				if !passwordResetRoutes[r.URL.Path] {
+					logger.Info(newLog(r.Context(), "Request for %s by user %s who must change their password", r.URL.Path, userID))
+					appMetrics.AuthForbidden()
+					problem.Write(w, r, problem.PasswordResetRequired, "Password must be changed with PUT /password")
+					return
+				}
+				err = nil
+			}
+			if err != nil {
+				logger.Error(newLog(r.Context(), "Unable to authorize request for %s: %s", r.URL.Path, err.Error()))
+				problem.Write(w, r, problem.Internal, "Unable to authorize request")
+				return
+			}
+
+			if scope != "" && !auth.Allows(auth.ScopesFromContext(ctx), scope) {
+				logger.Info(newLog(r.Context(), "Request for %s by user %s is missing scope %s", r.URL.Path, userID, scope))
+				appMetrics.AuthForbidden()
+				problem.Write(w, r, problem.Forbidden, fmt.Sprintf("Missing scope %s", scope))
+				return
+			}
+
+			if orgID := r.Header.Get(organizationHeader); orgID != "" {
+				_, err := db.GetMembership(ctx, orgID, userID)
+				if errors.Is(err, data.ErrNotFound) {
+					logger.Info(newLog(r.Context(), "Request for %s by user %s is not a member of organization %s", r.URL.Path, userID, orgID))
+					appMetrics.AuthForbidden()
+					problem.Write(w, r, problem.Forbidden, fmt.Sprintf("Not a member of organization %s", orgID))
+					return
+				}
+				if err != nil {
+					logger.Error(newLog(r.Context(), "Unable to check membership of organization %s: %s", orgID, err.Error()))
+					problem.Write(w, r, problem.Internal, "Unable to authorize request")
+					return
+				}
+
+				ctx = auth.ContextWithOrganization(logs.ContextWithField(ctx, "organization_id", orgID), orgID)
+			}
+
+			appMetrics.AuthSucceeded()
+			next(userID, w, r.WithContext(logs.ContextWithField(ctx, "user_id", userID)))
+		})
+	}
+}
+
+// newAdminMiddleware returns middleware which only passes requests on to the wrapped handler when the user is an admin,
+// either flagged with is_admin or listed by username under admins in conf.json
+func newAdminMiddleware(logger logs.Logger, db data.Connection) func(next func(userID string, w http.ResponseWriter, r *http.Request)) func(userID string, w http.ResponseWriter, r *http.Request) {
+	return func(next func(userID string, w http.ResponseWriter, r *http.Request)) func(userID string, w http.ResponseWriter, r *http.Request) {
+		return func(userID string, w http.ResponseWriter, r *http.Request) {
+			user, err := db.GetUser(r.Context(), userID)
+			if err != nil {
+				logger.Error(newLog(r.Context(), "Unable to find user %s: %s", userID, err.Error()))
+				problem.Write(w, r, problem.Internal, "Unable to authorize request")
+				return
+			}
+
+			if !user.IsAdmin && !isConfiguredAdmin(user.Username) {
+				logger.Info(newLog(r.Context(), "Request for %s by user %s who is not an admin", r.URL.Path, userID))
+				appMetrics.AuthForbidden()
+				problem.Write(w, r, problem.Forbidden, "Admin access required")
+				return
+			}
+
+			next(userID, w, r)
+		}
+	}
+}
+
+// newUserRateLimitMiddleware returns middleware which limits requests to the wrapped handler per authenticated user,
+// counting them against the route group's limit returned by limit at the time of each request.
+func newUserRateLimitMiddleware(logger logs.Logger, store ratelimit.Store, group string, limit func() ratelimit.Limit) func(next func(userID string, w http.ResponseWriter, r *http.Request)) func(userID string, w http.ResponseWriter, r *http.Request) {
+	return func(next func(userID string, w http.ResponseWriter, r *http.Request)) func(userID string, w http.ResponseWriter, r *http.Request) {
+		return func(userID string, w http.ResponseWriter, r *http.Request) {
+			if !allowRequest(logger, store, group, "user:"+userID, limit(), w, r) {
+				return
+			}
+			next(userID, w, r)
+		}
+	}
+}
+
+// newIPRateLimitMiddleware returns middleware which limits requests to the wrapped handler per client IP address,
+// counting them against the route group's limit. It is used for routes which are not authenticated.
+func newIPRateLimitMiddleware(logger logs.Logger, store ratelimit.Store, group string, limit func() ratelimit.Limit) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !allowRequest(logger, store, group, "ip:"+handlers.ClientIP(r), limit(), w, r) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowRequest takes a request from key's bucket in the route group, setting the X-RateLimit headers.
+// A 429 response is written and false returned if the bucket is empty. Requests are allowed if the store fails.
+func allowRequest(logger logs.Logger, store ratelimit.Store, group string, key string, limit ratelimit.Limit, w http.ResponseWriter, r *http.Request) bool {
+	if !limit.Enabled() {
+		return true
+	}
+
+	res, err := store.Take(r.Context(), group+":"+key, limit)
+	if err != nil {
+		logger.Error(newLog(r.Context(), "Unable to check rate limit of %s for %s: %s", key, r.URL.Path, err.Error()))
+		return true
+	}
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+	if !res.Allowed {
+		logger.Info(newLog(r.Context(), "Request for %s by %s exceeded the %s rate limit", r.URL.Path, key, group))
+		appMetrics.RateLimited(group)
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+		problem.Write(w, r, problem.RateLimited, "Rate limit exceeded, try again later")
+		return false
+	}
+
+	return true
+}
+
+// ceilSeconds rounds a duration up to whole seconds, for headers which count seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// isConfiguredAdmin reports whether a username is listed under admins in conf.json
+func isConfiguredAdmin(username string) bool {
+	for _, admin := range currentConfig().Admins {
+		if admin == username {
+			return true
+		}
+	}
+	return false
+}
+
+// requestCredential returns the API key or token sent with a request. Tokens may be sent with or without the Bearer scheme.
+func requestCredential(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > len("Bearer ") && strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		return authorization[len("Bearer "):]
+	}
+	return authorization
+}
+
+// authenticateToken verifies a token, returning the user it was issued to and a context carrying its claims and scopes
+func authenticateToken(ctx context.Context, keys *auth.KeySet, db data.Connection, token string) (string, context.Context, error) {
+	claims, err := keys.Parse(token)
+	if err != nil {
+		return "", ctx, fmt.Errorf("%w: %s", errUnauthorized, err)
+	}
+
+	jti := auth.TokenID(claims)
+	if jti == "" {
+		return "", ctx, fmt.Errorf("%w: token has no jti", errUnauthorized)
+	}
+
+	revoked, err := db.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return "", ctx, err
+	}
+	if revoked {
+		return "", ctx, fmt.Errorf("%w: token %s has been revoked", errUnauthorized, jti)
+	}
+
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return "", ctx, fmt.Errorf("%w: token has no user_id", errUnauthorized)
+	}
+
+	scope, _ := claims["scope"].(string)
+	ctx = auth.ContextWithScopes(auth.ContextWithClaims(ctx, claims), strings.Fields(scope))
+
+	return userID, ctx, nil
+}
+
+// checkUser ensures the user a request was authenticated as is still active, and that a token they sent was issued after
+// their tokens were last revoked by an admin. It returns errPasswordResetRequired once the user's credentials are
+// otherwise valid if an admin has reset their password.
+func checkUser(ctx context.Context, db data.Connection, userID string) error {
+	user, err := db.GetUser(ctx, userID)
+	if errors.Is(err, data.ErrNotFound) {
+		return fmt.Errorf("%w: user %s no longer exists", errUnauthorized, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.DisabledAt.Valid {
+		return fmt.Errorf("%w: user %s is disabled", errUnauthorized, userID)
+	}
+
+	claims := auth.ClaimsFromContext(ctx)
+	if claims == nil || !user.TokensRevokedAt.Valid {
+		return passwordResetError(user)
+	}
+
+	revokedAt, err := time.Parse(time.RFC3339Nano, user.TokensRevokedAt.String)
+	if err != nil {
+		return err
+	}
+	if auth.IssuedAt(claims).Before(revokedAt.Add(-revocationLeeway)) {
+		return fmt.Errorf("%w: token %s was issued before the tokens of user %s were revoked", errUnauthorized, auth.TokenID(claims), userID)
+	}
+
+	return passwordResetError(user)
+}
+
+// passwordResetError returns errPasswordResetRequired if an admin has reset the user's password
+func passwordResetError(user model.User) error {
+	if user.PasswordResetRequired {
+		return fmt.Errorf("%w: user %s must change their password", errPasswordResetRequired, user.ID)
+	}
+	return nil
+}
+
+// authenticateAPIKey checks an API key is active, returning the user it belongs to and a context carrying its scopes
+func authenticateAPIKey(ctx context.Context, keys *auth.KeySet, db data.Connection, key string) (string, context.Context, error) {
+	apiKey, err := db.AuthenticateAPIKey(ctx, auth.HashToken(key))
+	if errors.Is(err, data.ErrNotFound) {
+		return "", ctx, fmt.Errorf("%w: API key is invalid, expired or revoked", errUnauthorized)
+	}
+	if err != nil {
+		return "", ctx, err
+	}
+
+	ctx = auth.ContextWithScopes(logs.ContextWithField(ctx, "api_key_id", apiKey.ID), apiKey.Scopes)
+
+	return apiKey.UserID, ctx, nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/auth"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/handlers"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// recordingLogger keeps every log written to it
+type recordingLogger struct {
+	mu   sync.Mutex
+	logs []logs.LogStruct
+}
+
+func (l *recordingLogger) record(log logs.LogStruct) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, log)
+}
+
+func (l *recordingLogger) SetLevel(int)               {}
+func (l *recordingLogger) Fatal(log logs.LogStruct)   { l.record(log) }
+func (l *recordingLogger) Error(log logs.LogStruct)   { l.record(log) }
+func (l *recordingLogger) Warning(log logs.LogStruct) { l.record(log) }
+func (l *recordingLogger) Info(log logs.LogStruct)    { l.record(log) }
+func (l *recordingLogger) Debug(log logs.LogStruct)   { l.record(log) }
+func (l *recordingLogger) Verbose(log logs.LogStruct) { l.record(log) }
+
+func newTestKeySet(t *testing.T) *auth.KeySet {
+	t.Helper()
+
+	keys, err := auth.NewKeySet(auth.Config{
+		SigningKeyID: "key-1",
+		Keys:         []auth.KeyConfig{{ID: "key-1", Algorithm: "HS256", Secret: "test-secret"}},
+	})
+	if err != nil {
+		t.Fatalf("NewKeySet: %s", err)
+	}
+	return keys
+}
+
+func TestAuthMiddlewareLogsRequestID(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{"no credentials", ""},
+		{"invalid token", "Bearer not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			middleware := newAuthMiddleware(logger, newTestKeySet(t), data.NewMemory(logs.NewStdLogger(logs.LogLevelFatal)))
+			handler := handlers.RequestID(middleware("", func(string, http.ResponseWriter, *http.Request) {
+				t.Errorf("unauthorized request passed on")
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/lambdas", nil)
+			r.Header.Set(handlers.RequestIDHeader, "req-123")
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, r)
+
+			if rw.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d", rw.Code, http.StatusUnauthorized)
+			}
+			if !strings.Contains(rw.Body.String(), `"request_id":"req-123"`) {
+				t.Errorf("problem is missing the request ID: %s", rw.Body.String())
+			}
+			if len(logger.logs) != 1 {
+				t.Fatalf("logged %d lines, want 1", len(logger.logs))
+			}
+			if id := logger.logs[0].Fields["request_id"]; id != "req-123" {
+				t.Errorf("request_id = %v, want req-123 in %+v", id, logger.logs[0])
+			}
+		})
+	}
+}
+
+func TestCheckUserRevokedTokens(t *testing.T) {
+	db := data.NewMemory(logs.NewStdLogger(logs.LogLevelFatal))
+
+	user, err := db.CreateUser(context.Background(), "alice", "password123")
+	if err != nil {
+		t.Fatalf("CreateUser: %s", err)
+	}
+	err = db.ChangePassword(context.Background(), user.ID, "password456")
+	if err != nil {
+		t.Fatalf("ChangePassword: %s", err)
+	}
+
+	changed, _ := db.GetUser(context.Background(), user.ID)
+	revokedAt, err := time.Parse(time.RFC3339Nano, changed.TokensRevokedAt.String)
+	if err != nil {
+		t.Fatalf("tokens revoked at %q: %s", changed.TokensRevokedAt.String, err)
+	}
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		wantErr  error
+	}{
+		{"issued before the revocation", revokedAt.Add(-time.Second), errUnauthorized},
+		{"issued within the leeway", revokedAt.Add(-revocationLeeway / 2), nil},
+		{"issued as tokens were revoked", revokedAt, nil},
+		{"issued after the revocation", revokedAt.Add(time.Second), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{
+				"jti": "token-1",
+				"iat": float64(tt.issuedAt.UnixNano()) / float64(time.Second),
+			}
+
+			err := checkUser(auth.ContextWithClaims(context.Background(), claims), db, user.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareAPIKeys(t *testing.T) {
+	db := data.NewMemory(logs.NewStdLogger(logs.LogLevelFatal))
+	user, err := db.CreateUser(context.Background(), "alice", "password123")
+	if err != nil {
+		t.Fatalf("CreateUser: %s", err)
+	}
+
+	// newKey stores an API key for alice, returning the key to send
+	newKey := func(scopes []string, expiresAt string, revoke bool) string {
+		key, prefix, hash, err := auth.NewAPIKey()
+		if err != nil {
+			t.Fatalf("NewAPIKey: %s", err)
+		}
+		stored, err := db.CreateAPIKey(context.Background(), model.APIKey{
+			UserID:    user.ID,
+			Name:      "test",
+			Prefix:    prefix,
+			KeyHash:   hash,
+			Scopes:    scopes,
+			ExpiresAt: sql.NullString{String: expiresAt, Valid: expiresAt != ""},
+		})
+		if err != nil {
+			t.Fatalf("CreateAPIKey: %s", err)
+		}
+		if revoke {
+			if err := db.RevokeAPIKey(context.Background(), user.ID, stored.ID); err != nil {
+				t.Fatalf("RevokeAPIKey: %s", err)
+			}
+		}
+		return key
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano)
+
+	tests := []struct {
+		name  string
+		key   string
+		scope string
+		want  int
+	}{
+		{"full access", newKey([]string{auth.FullAccess}, "", false), "lambdas:write", http.StatusOK},
+		{"granted scope", newKey([]string{"lambdas:read"}, future, false), "lambdas:read", http.StatusOK},
+		{"missing scope", newKey([]string{"lambdas:read"}, "", false), "lambdas:write", http.StatusForbidden},
+		{"audit log without audit:read", newKey([]string{"lambdas:write"}, "", false), "audit:read", http.StatusForbidden},
+		{"expired", newKey([]string{auth.FullAccess}, past, false), "lambdas:read", http.StatusUnauthorized},
+		{"revoked", newKey([]string{auth.FullAccess}, "", true), "lambdas:read", http.StatusUnauthorized},
+		{"unknown", auth.APIKeyPrefix + "unknown", "lambdas:read", http.StatusUnauthorized},
+	}
+
+	middleware := newAuthMiddleware(logs.NewStdLogger(logs.LogLevelFatal), newTestKeySet(t), db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware(tt.scope, func(userID string, rw http.ResponseWriter, r *http.Request) {
+				if userID != user.ID {
+					t.Errorf("authorized as %s, want %s", userID, user.ID)
+				}
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/lambdas", nil)
+			r.Header.Set(apiKeyHeader, tt.key)
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, r)
+
+			if rw.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rw.Code, tt.want, rw.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// APIKey is a long lived credential for machine clients. Only a hash of the key is stored.
+type APIKey struct {
+	ID         string         `db:"id"`
+	UserID     string         `db:"user_id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	KeyHash    string         `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	ExpiresAt  sql.NullString `db:"expires_at"`
+	LastUsedAt sql.NullString `db:"last_used_at"`
+	CreatedAt  time.Time      `db:"created_at"`
+	RevokedAt  sql.NullString `db:"revoked_at"`
+}
+
+// APIKeys is a list of APIKey
+type APIKeys []APIKey
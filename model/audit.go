@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	// AuditCreate records a resource being created
+	AuditCreate = "create"
+	// AuditUpdate records a resource being changed
+	AuditUpdate = "update"
+	// AuditDelete records a resource being deleted
+	AuditDelete = "delete"
+	// AuditRestore records a deleted resource being restored
+	AuditRestore = "restore"
+	// AuditRegister records a user registering
+	AuditRegister = "register"
+	// AuditSignIn records a user signing in
+	AuditSignIn = "sign_in"
+	// AuditSignOut records a user signing out
+	AuditSignOut = "sign_out"
+	// AuditChangePassword records a user changing their password
+	AuditChangePassword = "change_password"
+	// AuditDisable records an admin disabling a user
+	AuditDisable = "disable"
+	// AuditEnable records an admin enabling a user
+	AuditEnable = "enable"
+	// AuditResetPassword records an admin resetting a user's password
+	AuditResetPassword = "reset_password"
+)
+
+// AuditEvent records an action a user took. Before and After are JSON snapshots of the resource, which are null
+// when it did not exist or no snapshot applies.
+type AuditEvent struct {
+	ID           string          `db:"id" json:"id"`
+	UserID       string          `db:"user_id" json:"user_id"`
+	Action       string          `db:"action" json:"action"`
+	ResourceType string          `db:"resource_type" json:"resource_type"`
+	ResourceID   string          `db:"resource_id" json:"resource_id"`
+	Before       json.RawMessage `db:"before" json:"before"`
+	After        json.RawMessage `db:"after" json:"after"`
+	SourceIP     string          `db:"source_ip" json:"source_ip"`
+	RequestID    string          `db:"request_id" json:"request_id"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+}
+
+// AuditEvents is a list of AuditEvent
+type AuditEvents []AuditEvent
+
+// FromJSON serializes data from json
+func (a *AuditEvents) FromJSON(data io.Reader) error {
+	de := json.NewDecoder(data)
+	return de.Decode(a)
+}
+
+// ToJSON converts the collection to json
+func (a *AuditEvents) ToJSON() ([]byte, error) {
+	return json.Marshal(a)
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// IdempotencyRecord stores the response to a create request so a retry with the same key can be replayed.
+// A StatusCode of zero means the original request is still being processed.
+type IdempotencyRecord struct {
+	UserID      string    `db:"user_id"`
+	Key         string    `db:"idempotency_key"`
+	RequestHash string    `db:"request_hash"`
+	StatusCode  int       `db:"status_code"`
+	Headers     string    `db:"headers"`
+	Body        []byte    `db:"body"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   string    `db:"expires_at"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+const (
+	// FilterEquals matches rows where the field is equal to the value
+	FilterEquals = "eq"
+	// FilterPrefix matches rows where the field starts with the value
+	FilterPrefix = "prefix"
+	// FilterGreaterOrEqual matches rows where the field is greater than or equal to the value
+	FilterGreaterOrEqual = "gte"
+	// FilterLessOrEqual matches rows where the field is less than or equal to the value
+	FilterLessOrEqual = "lte"
+	// FilterContains matches rows where the field, a set of tags, has every tag in the value
+	FilterContains = "contains"
+)
+
+// Filter restricts a list to rows where Field compares to Value using Operator
+type Filter struct {
+	Field    string
+	Operator string
+	Value    interface{}
+}
+
+// ListOptions describes pagination, sorting and filtering of a list request
+type ListOptions struct {
+	// Limit is the maximum number of rows to return, zero returns every row
+	Limit int
+	// Cursor is the opaque position returned with the previous page
+	Cursor string
+	// Sort is the field to order by, defaulting to created_at
+	Sort string
+	// Descending reverses the sort order
+	Descending bool
+	Filters    []Filter
+	// DeletedWithin lists rows deleted within the duration instead of live rows, when set
+	DeletedWithin time.Duration
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	// RoleOwner can do anything in an organization, including deleting it and managing other owners
+	RoleOwner = "owner"
+	// RoleAdmin can manage the organization's members as well as its resources
+	RoleAdmin = "admin"
+	// RoleMember can list and manage the organization's resources
+	RoleMember = "member"
+)
+
+// roleRanks orders roles from least to most privileged
+var roleRanks = map[string]int{RoleMember: 1, RoleAdmin: 2, RoleOwner: 3}
+
+// ValidRole reports whether role is one of the organization roles
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// RoleAtLeast reports whether role is as privileged as min
+func RoleAtLeast(role string, min string) bool {
+	return roleRanks[role] >= roleRanks[min]
+}
+
+// Organization is a team of users who share ownership of resources
+type Organization struct {
+	ID        string         `db:"id" json:"id"`
+	Name      string         `db:"name" json:"name"`
+	Role      string         `db:"role" json:"role,omitempty"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+	DeletedAt sql.NullString `db:"deleted_at" json:"-"`
+}
+
+// FromJSON converts data from JSON
+func (o *Organization) FromJSON(data io.Reader) error {
+	de := json.NewDecoder(data)
+	return de.Decode(o)
+}
+
+// ToJSON converts data to JSON
+func (o *Organization) ToJSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+// Organizations is a list of Organization
+type Organizations []Organization
+
+// ToJSON converts data to JSON
+func (o *Organizations) ToJSON() ([]byte, error) {
+	return json.Marshal(o)
+}
+
+// Membership gives a user a role in an organization
+type Membership struct {
+	OrganizationID string    `db:"organization_id" json:"-"`
+	UserID         string    `db:"user_id" json:"user_id"`
+	Username       string    `db:"username" json:"username"`
+	Role           string    `db:"role" json:"role"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// ToJSON converts data to JSON
+func (m *Membership) ToJSON() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// Memberships is a list of Membership
+type Memberships []Membership
+
+// ToJSON converts data to JSON
+func (m *Memberships) ToJSON() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// DefaultQuotaUser is the user id of the quotas which apply to users without their own
+const DefaultQuotaUser = "*"
+
+// Quota limits how many resources of a type a user can hold, and their total capacity.
+// A nil limit is unlimited.
+type Quota struct {
+	UserID       string `db:"user_id" json:"-"`
+	ResourceType string `db:"resource_type" json:"resource_type"`
+	MaxCount     *int   `db:"max_count" json:"max_count"`
+	MaxCapacity  *int   `db:"max_capacity" json:"max_capacity"`
+}
+
+// QuotaUsage is a user's current usage of a resource type against their quota
+type QuotaUsage struct {
+	ResourceType string `db:"resource_type" json:"resource_type"`
+	Count        int    `db:"count" json:"count"`
+	MaxCount     *int   `db:"max_count" json:"max_count"`
+	Capacity     int    `db:"capacity" json:"capacity"`
+	MaxCapacity  *int   `db:"max_capacity" json:"max_capacity"`
+	CapacityUnit string `db:"capacity_unit" json:"capacity_unit"`
+}
+
+// QuotaUsages is a list of QuotaUsage
+type QuotaUsages []QuotaUsage
+
+// FromJSON converts data from JSON
+func (q *QuotaUsages) FromJSON(data io.Reader) error {
+	de := json.NewDecoder(data)
+	return de.Decode(q)
+}
+
+// ToJSON converts data to JSON
+func (q *QuotaUsages) ToJSON() ([]byte, error) {
+	return json.Marshal(q)
+}
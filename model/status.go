@@ -0,0 +1,30 @@
+package model
+
+const (
+	// StatusPending is a resource which has been requested but not yet picked up
+	StatusPending = "pending"
+	// StatusProvisioning is a resource which is being created
+	StatusProvisioning = "provisioning"
+	// StatusRunning is a resource which is ready to use
+	StatusRunning = "running"
+	// StatusDeleting is a resource which is being torn down
+	StatusDeleting = "deleting"
+	// StatusDeleted is a resource which has been torn down
+	StatusDeleted = "deleted"
+	// StatusFailed is a resource which could not be provisioned
+	StatusFailed = "failed"
+)
+
+// ResourceRef identifies a resource of any type along with its lifecycle status
+type ResourceRef struct {
+	Type   string `db:"type"`
+	ID     string `db:"id"`
+	Status string `db:"status"`
+}
+
+// ResourceCount is the number of live resources of a type in a status
+type ResourceCount struct {
+	Type   string `db:"type"`
+	Status string `db:"status"`
+	Count  int    `db:"count"`
+}
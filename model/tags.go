@@ -0,0 +1,49 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Tags are key/value labels users attach to resources, such as an owner, environment or cost center
+type Tags map[string]string
+
+// Value stores tags as a JSON object. Nil tags are stored as NULL.
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	d, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(d), nil
+}
+
+// Scan reads tags stored as a JSON object
+func (t *Tags) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = Tags{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	}
+
+	return fmt.Errorf("unable to scan %T into tags", src)
+}
+
+// Contains reports whether every tag in other is set to the same value in t
+func (t Tags) Contains(other Tags) bool {
+	for key, value := range other {
+		if v, ok := t[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RefreshToken is a long lived token which can be exchanged for a new access token.
+// Only a hash of the token is stored.
+type RefreshToken struct {
+	ID        string         `db:"id"`
+	UserID    string         `db:"user_id"`
+	TokenHash string         `db:"token_hash"`
+	Scopes    pq.StringArray `db:"scopes"`
+	CreatedAt time.Time      `db:"created_at"`
+	ExpiresAt string         `db:"expires_at"`
+	RevokedAt sql.NullString `db:"revoked_at"`
+}
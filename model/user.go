@@ -4,16 +4,21 @@ import (
 	"database/sql"
 	"encoding/json"
 	"io"
+	"time"
 )
 
 // User defines a user in the database
 type User struct {
-	ID        string         `db:"id" json:"id"`
-	Username  string         `db:"username" json:"username"`
-	Password  string         `db:"password" json:"-"`
-	CreatedAt string         `db:"created_at" json:"-"`
-	UpdatedAt string         `db:"updated_at" json:"-"`
-	DeletedAt sql.NullString `db:"deleted_at" json:"-"`
+	ID                    string         `db:"id" json:"id"`
+	Username              string         `db:"username" json:"username"`
+	Password              string         `db:"password" json:"-"`
+	IsAdmin               bool           `db:"is_admin" json:"-"`
+	DisabledAt            sql.NullString `db:"disabled_at" json:"-"`
+	TokensRevokedAt       sql.NullString `db:"tokens_revoked_at" json:"-"`
+	PasswordResetRequired bool           `db:"password_reset_required" json:"-"`
+	CreatedAt             time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt             time.Time      `db:"updated_at" json:"updated_at"`
+	DeletedAt             sql.NullString `db:"deleted_at" json:"-"`
 }
 
 // FromJSON serializes data from json
@@ -26,3 +31,6 @@ func (u *User) FromJSON(data io.Reader) error {
 func (u *User) ToJSON() ([]byte, error) {
 	return json.Marshal(u)
 }
+
+// Users is a list of User
+type Users []User
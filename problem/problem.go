@@ -0,0 +1,91 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/danielpadmore/cloudygo-service/logs"
+)
+
+// ContentType is the media type of error responses, described in RFC 7807
+const ContentType = "application/problem+json"
+
+// Type is a kind of error, identified by a code clients can rely on, and the status it is returned with
+type Type struct {
+	Code   string
+	Status int
+}
+
+// The kinds of error returned by the API. Their codes must not change once released.
+var (
+	InvalidRequest        = Type{"invalid_request", http.StatusBadRequest}
+	ValidationFailed      = Type{"validation_failed", http.StatusBadRequest}
+	Unauthorized          = Type{"unauthorized", http.StatusUnauthorized}
+	Forbidden             = Type{"forbidden", http.StatusForbidden}
+	QuotaExceeded         = Type{"quota_exceeded", http.StatusForbidden}
+	PasswordResetRequired = Type{"password_reset_required", http.StatusForbidden}
+	NotFound              = Type{"not_found", http.StatusNotFound}
+	MethodNotAllowed      = Type{"method_not_allowed", http.StatusMethodNotAllowed}
+	Conflict              = Type{"conflict", http.StatusConflict}
+	AlreadyExists         = Type{"already_exists", http.StatusConflict}
+	PreconditionFailed    = Type{"precondition_failed", http.StatusPreconditionFailed}
+	VersionMismatch       = Type{"version_mismatch", http.StatusPreconditionFailed}
+	UnsupportedMediaType  = Type{"unsupported_media_type", http.StatusUnsupportedMediaType}
+	IdempotencyKeyReused  = Type{"idempotency_key_reused", http.StatusUnprocessableEntity}
+	LockedOut             = Type{"locked_out", http.StatusTooManyRequests}
+	RateLimited           = Type{"rate_limited", http.StatusTooManyRequests}
+	Internal              = Type{"internal_error", http.StatusInternalServerError}
+)
+
+// FieldError describes why a single field of a request body is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Problem is the body of an error response
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Code      string       `json:"code"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// New describes an error of type t which occurred handling r
+func New(r *http.Request, t Type, detail string, fields ...FieldError) Problem {
+	id, _ := logs.ContextField(r.Context(), logs.RequestIDField)
+	requestID, _ := id.(string)
+
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(t.Status),
+		Status:    t.Status,
+		Code:      t.Code,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: requestID,
+		Errors:    fields,
+	}
+}
+
+// Write responds to r with an error of type t. The detail is a human readable explanation and fields
+// lists the invalid fields of the request body, if any.
+func Write(rw http.ResponseWriter, r *http.Request, t Type, detail string, fields ...FieldError) {
+	p := New(r, t, detail, fields...)
+
+	d, err := json.Marshal(p)
+	if err != nil {
+		http.Error(rw, detail, t.Status)
+		return
+	}
+
+	rw.Header().Set("Content-Type", ContentType)
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(t.Status)
+	rw.Write(d)
+}
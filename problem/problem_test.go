@@ -0,0 +1,104 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/danielpadmore/cloudygo-service/logs"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		t         Type
+		detail    string
+		fields    []FieldError
+		requestID string
+		want      Problem
+	}{
+		{
+			name:   "not found",
+			t:      NotFound,
+			detail: "Failed to find lambda",
+			want: Problem{
+				Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Code: "not_found",
+				Detail: "Failed to find lambda", Instance: "/lambdas/1",
+			},
+		},
+		{
+			name:   "validation failed with fields",
+			t:      ValidationFailed,
+			detail: "name is required",
+			fields: []FieldError{{Field: "name", Rule: "required", Message: "name is required"}},
+			want: Problem{
+				Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest, Code: "validation_failed",
+				Detail: "name is required", Instance: "/lambdas/1",
+				Errors: []FieldError{{Field: "name", Rule: "required", Message: "name is required"}},
+			},
+		},
+		{
+			name:      "request id from request context",
+			t:         Conflict,
+			detail:    "Lambda is being deleted",
+			requestID: "abc-123",
+			want: Problem{
+				Type: "about:blank", Title: "Conflict", Status: http.StatusConflict, Code: "conflict",
+				Detail: "Lambda is being deleted", Instance: "/lambdas/1", RequestID: "abc-123",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/lambdas/1?x=y", nil)
+			if tt.requestID != "" {
+				r = r.WithContext(logs.ContextWithField(r.Context(), logs.RequestIDField, tt.requestID))
+			}
+
+			rw := httptest.NewRecorder()
+			Write(rw, r, tt.t, tt.detail, tt.fields...)
+
+			if rw.Code != tt.t.Status {
+				t.Errorf("status = %d, want %d", rw.Code, tt.t.Status)
+			}
+			if ct := rw.Header().Get("Content-Type"); ct != ContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, ContentType)
+			}
+			if nosniff := rw.Header().Get("X-Content-Type-Options"); nosniff != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", nosniff)
+			}
+
+			got := Problem{}
+			if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body is not JSON: %s", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteOmitsEmptyMembers(t *testing.T) {
+	rw := httptest.NewRecorder()
+	Write(rw, httptest.NewRequest(http.MethodGet, "/", nil), Internal, "")
+
+	members := map[string]interface{}{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &members); err != nil {
+		t.Fatalf("body is not JSON: %s", err)
+	}
+
+	for _, member := range []string{"detail", "request_id", "errors"} {
+		if _, ok := members[member]; ok {
+			t.Errorf("body has empty member %s", member)
+		}
+	}
+	for _, member := range []string{"type", "title", "status", "code", "instance"} {
+		if _, ok := members[member]; !ok {
+			t.Errorf("body is missing member %s", member)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package provisioner
+
+import (
+	"fmt"
+
+	"github.com/danielpadmore/cloudygo-service/logs"
+)
+
+func newLog(message string, a ...interface{}) logs.LogStruct {
+	return logs.NewLog("PROVISIONER", fmt.Sprintf(message, a...))
+}
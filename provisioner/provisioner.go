@@ -0,0 +1,102 @@
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/config"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/model"
+)
+
+// defaultInterval is used when no interval is configured
+const defaultInterval = time.Second
+
+// Config controls how quickly resources move through their lifecycle and how often provisioning fails
+type Config struct {
+	Interval          config.Duration `json:"interval"`
+	PendingDelay      config.Duration `json:"pending_delay"`
+	ProvisioningDelay config.Duration `json:"provisioning_delay"`
+	DeletingDelay     config.Duration `json:"deleting_delay"`
+	FailureRate       float64         `json:"failure_rate"`
+}
+
+// Worker simulates asynchronous provisioning by advancing resources through their lifecycle in the background
+type Worker struct {
+	logger     logs.Logger
+	connection data.Connection
+	config     func() Config
+	random     *rand.Rand
+}
+
+// New creates a new Worker. Each pass uses the config returned by config at the time.
+func New(logger logs.Logger, connection data.Connection, config func() Config) *Worker {
+	return &Worker{logger, connection, config, rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// Run advances resources every interval until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	w.logger.Info(newLog("Starting provisioner"))
+
+	for {
+		interval := w.config().Interval.Duration
+		if interval <= 0 {
+			interval = defaultInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			w.logger.Info(newLog("Stopped provisioner"))
+			return
+		case <-time.After(interval):
+			w.advance(ctx, w.config())
+		}
+	}
+}
+
+// advance moves every resource which has waited long enough in its current status on to the next
+func (w *Worker) advance(ctx context.Context, conf Config) {
+	w.transition(ctx, model.StatusPending, conf.PendingDelay.Duration, func() string {
+		return model.StatusProvisioning
+	})
+
+	w.transition(ctx, model.StatusProvisioning, conf.ProvisioningDelay.Duration, func() string {
+		if w.random.Float64() < conf.FailureRate {
+			return model.StatusFailed
+		}
+		return model.StatusRunning
+	})
+
+	w.transition(ctx, model.StatusDeleting, conf.DeletingDelay.Duration, func() string {
+		return model.StatusDeleted
+	})
+}
+
+func (w *Worker) transition(ctx context.Context, from string, delay time.Duration, next func() string) {
+	refs, err := w.connection.GetResourcesByStatus(ctx, from, delay)
+	if err != nil {
+		w.logger.Error(newLog("Unable to find %s resources: %s", from, err.Error()))
+		return
+	}
+
+	for _, ref := range refs {
+		to := next()
+
+		fields := map[string]interface{}{"resource_type": ref.Type, "resource_id": ref.ID}
+
+		err := w.connection.TransitionResource(ctx, ref, to)
+		if errors.Is(err, data.ErrNotFound) {
+			w.logger.Debug(newLog("%s %s changed before it could be moved to %s", ref.Type, ref.ID, to).WithFields(fields))
+			continue
+		}
+		if err != nil {
+			w.logger.Error(newLog("Unable to move %s %s to %s: %s", ref.Type, ref.ID, to, err.Error()).WithFields(fields))
+			continue
+		}
+
+		w.logger.Info(newLog("%s %s is now %s", ref.Type, ref.ID, to).WithFields(fields))
+	}
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets which have refilled are forgotten
+const sweepInterval = time.Minute
+
+// bucket is the state of a single key's token bucket
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// Memory implements Store in process, so each instance of the service limits requests separately
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+// NewMemory creates an empty in-process Store
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]*bucket{}, swept: time.Now()}
+}
+
+// Take removes a request from key's bucket if there is one left, refilling it for the time since it was last used
+func (m *Memory) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	capacity, interval := limit.capacity(), limit.interval()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.updated))/float64(interval))
+	b.updated = now
+
+	res := Result{Limit: int(capacity)}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((capacity - b.tokens) * float64(interval))
+	b.full = now.Add(res.Reset)
+
+	return res, nil
+}
+
+// sweep forgets buckets which have refilled, as they are the same as a new bucket. Callers must hold m.mu.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.swept) < sweepInterval {
+		return
+	}
+
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+	m.swept = now
+}
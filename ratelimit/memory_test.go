@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/config"
+)
+
+func TestMemoryTake(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       Limit
+		takes       int
+		wantAllowed int
+		wantLimit   int
+	}{
+		{"requests fill the bucket", Limit{Requests: 3, Per: config.Duration{Duration: time.Hour}}, 5, 3, 3},
+		{"burst overrides capacity", Limit{Requests: 3, Per: config.Duration{Duration: time.Hour}, Burst: 1}, 5, 1, 1},
+		{"under the limit", Limit{Requests: 10, Per: config.Duration{Duration: time.Hour}}, 4, 4, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory()
+
+			allowed := 0
+			var last Result
+			for i := 0; i < tt.takes; i++ {
+				res, err := m.Take(context.Background(), "key", tt.limit)
+				if err != nil {
+					t.Fatalf("Take: %s", err)
+				}
+				if res.Allowed {
+					allowed++
+				}
+				last = res
+			}
+
+			if allowed != tt.wantAllowed {
+				t.Errorf("allowed %d requests, want %d", allowed, tt.wantAllowed)
+			}
+			if last.Limit != tt.wantLimit {
+				t.Errorf("Limit = %d, want %d", last.Limit, tt.wantLimit)
+			}
+			if last.Remaining != tt.wantLimit-allowed {
+				t.Errorf("Remaining = %d, want %d", last.Remaining, tt.wantLimit-allowed)
+			}
+			if !last.Allowed && last.RetryAfter <= 0 {
+				t.Errorf("RetryAfter = %s for a refused request, want > 0", last.RetryAfter)
+			}
+			if last.Reset <= 0 {
+				t.Errorf("Reset = %s after taking requests, want > 0", last.Reset)
+			}
+		})
+	}
+}
+
+func TestMemoryTakeRefills(t *testing.T) {
+	m := NewMemory()
+	limit := Limit{Requests: 1, Per: config.Duration{Duration: 20 * time.Millisecond}}
+
+	first, _ := m.Take(context.Background(), "key", limit)
+	second, _ := m.Take(context.Background(), "key", limit)
+	if !first.Allowed || second.Allowed {
+		t.Fatalf("allowed = %t, %t, want true, false", first.Allowed, second.Allowed)
+	}
+	if second.RetryAfter > limit.Per.Duration {
+		t.Errorf("RetryAfter = %s, want at most %s", second.RetryAfter, limit.Per.Duration)
+	}
+
+	time.Sleep(second.RetryAfter + 5*time.Millisecond)
+
+	third, _ := m.Take(context.Background(), "key", limit)
+	if !third.Allowed {
+		t.Errorf("request after RetryAfter was refused")
+	}
+}
+
+func TestMemoryTakeSeparatesKeys(t *testing.T) {
+	m := NewMemory()
+	limit := Limit{Requests: 1, Per: config.Duration{Duration: time.Hour}}
+
+	for _, key := range []string{"user:alice", "user:bob", "ip:127.0.0.1"} {
+		res, _ := m.Take(context.Background(), key, limit)
+		if !res.Allowed {
+			t.Errorf("first request for %s was refused", key)
+		}
+	}
+}
+
+func TestLimitEnabled(t *testing.T) {
+	tests := []struct {
+		limit Limit
+		want  bool
+	}{
+		{Limit{}, false},
+		{Limit{Requests: 10}, false},
+		{Limit{Per: config.Duration{Duration: time.Second}}, false},
+		{Limit{Requests: 10, Per: config.Duration{Duration: time.Second}}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.limit.Enabled(); got != tt.want {
+			t.Errorf("%+v.Enabled() = %t, want %t", tt.limit, got, tt.want)
+		}
+	}
+}
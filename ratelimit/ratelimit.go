@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/config"
+)
+
+// Limit is a token bucket which holds up to burst requests and refills at requests per duration.
+// Requests of 0 leaves the group unlimited.
+type Limit struct {
+	Requests int             `json:"requests"`
+	Per      config.Duration `json:"per"`
+	Burst    int             `json:"burst"`
+}
+
+// Config contains the limit of each route group
+type Config struct {
+	Auth      Limit `json:"auth"`
+	Resources Limit `json:"resources"`
+	Default   Limit `json:"default"`
+}
+
+// Enabled reports whether the limit restricts requests at all
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per.Duration > 0
+}
+
+// capacity returns how many requests the bucket holds when full
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// interval returns how long the bucket takes to refill a single request
+func (l Limit) interval() time.Duration {
+	return l.Per.Duration / time.Duration(l.Requests)
+}
+
+// Result describes the bucket after a request tried to take from it
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// Store holds the token buckets of every key. It is an interface so buckets can be shared between instances.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -0,0 +1,11 @@
+package reaper
+
+import (
+	"fmt"
+
+	"github.com/danielpadmore/cloudygo-service/logs"
+)
+
+func newLog(message string, a ...interface{}) logs.LogStruct {
+	return logs.NewLog("REAPER", fmt.Sprintf(message, a...))
+}
@@ -0,0 +1,77 @@
+package reaper
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/config"
+	"github.com/danielpadmore/cloudygo-service/data"
+	"github.com/danielpadmore/cloudygo-service/logs"
+)
+
+const (
+	// defaultInterval is used when no interval is configured
+	defaultInterval = time.Hour
+	// defaultRetention is how long deleted resources can be restored for when retention is not set
+	defaultRetention = 7 * 24 * time.Hour
+)
+
+// Config controls how long deleted resources can be restored for and how often those past it are purged
+type Config struct {
+	Retention config.Duration `json:"retention"`
+	Interval  config.Duration `json:"interval"`
+}
+
+// RetentionPeriod returns how long deleted resources are kept before they are purged
+func (c Config) RetentionPeriod() time.Duration {
+	if c.Retention.Duration <= 0 {
+		return defaultRetention
+	}
+	return c.Retention.Duration
+}
+
+// Worker hard deletes resources which have been deleted for longer than the retention period
+type Worker struct {
+	logger     logs.Logger
+	connection data.Connection
+	config     func() Config
+}
+
+// New creates a new Worker. Each pass uses the config returned by config at the time.
+func New(logger logs.Logger, connection data.Connection, config func() Config) *Worker {
+	return &Worker{logger, connection, config}
+}
+
+// Run purges resources every interval until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	w.logger.Info(newLog("Starting reaper"))
+
+	for {
+		interval := w.config().Interval.Duration
+		if interval <= 0 {
+			interval = defaultInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			w.logger.Info(newLog("Stopped reaper"))
+			return
+		case <-time.After(interval):
+			w.purge(ctx)
+		}
+	}
+}
+
+// purge hard deletes every resource past the retention period
+func (w *Worker) purge(ctx context.Context) {
+	refs, err := w.connection.PurgeResources(ctx, w.config().RetentionPeriod())
+	if err != nil {
+		w.logger.Error(newLog("Unable to purge deleted resources: %s", err.Error()))
+		return
+	}
+
+	for _, ref := range refs {
+		fields := map[string]interface{}{"resource_type": ref.Type, "resource_id": ref.ID}
+		w.logger.Info(newLog("Purged %s %s", ref.Type, ref.ID).WithFields(fields))
+	}
+}
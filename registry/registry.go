@@ -0,0 +1,295 @@
+package registry
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/danielpadmore/cloudygo-service/model"
+)
+
+// Kind is the type of value a field holds
+type Kind int
+
+const (
+	// String fields hold text
+	String Kind = iota
+	// Int fields hold whole numbers
+	Int
+)
+
+// nameRules are the validation rules of the name every resource has
+const nameRules = "required,min=5,max=200"
+
+// Field is a property of a resource type beyond those every resource has. It is stored in the column
+// with the same name as its JSON field.
+type Field struct {
+	Name     string
+	Kind     Kind
+	Validate string
+	// Secret fields are stored but can't be set or read through the API, and are never filtered on
+	Secret bool
+}
+
+// Quota is the limit which applies to users without their own quota for a resource type
+type Quota struct {
+	MaxCount    int
+	MaxCapacity int
+}
+
+// Type declares a type of cloud resource. Its routes, handlers, storage and catalog entry are derived from it.
+type Type struct {
+	// Name identifies the type in quotas, audit events and the catalog
+	Name string
+	// Title names the type in the resources catalog, under CatalogID
+	Title     string
+	CatalogID string
+	// Label is how messages refer to a single resource of the type
+	Label string
+	// Path is the route resources of the type are served under
+	Path string
+	// Table stores resources of the type, and is the resource their scopes grant access to
+	Table  string
+	Fields []Field
+	// Capacity lists the fields multiplied together to give how much of the capacity quota a resource uses,
+	// which is counted in CapacityUnit
+	Capacity     []string
+	CapacityUnit string
+	DefaultQuota Quota
+
+	row  reflect.Type
+	body reflect.Type
+}
+
+var (
+	types  = []*Type{}
+	byName = map[string]*Type{}
+)
+
+// Register adds a resource type, building the types of its rows and request bodies from its fields.
+// It panics if the declaration is invalid, as types are registered when the program starts.
+func Register(t Type) *Type {
+	if _, ok := byName[t.Name]; ok {
+		panic(fmt.Sprintf("resource type %s is already registered", t.Name))
+	}
+
+	declared := map[string]Field{}
+	for _, f := range t.Fields {
+		declared[f.Name] = f
+	}
+	for _, name := range t.Capacity {
+		if f, ok := declared[name]; !ok || f.Kind != Int {
+			panic(fmt.Sprintf("capacity of resource type %s uses %s which is not a number field", t.Name, name))
+		}
+	}
+
+	t.row = rowType(t.Fields)
+	t.body = bodyType(t.Fields)
+
+	types = append(types, &t)
+	byName[t.Name] = &t
+	return &t
+}
+
+// Types returns every registered resource type in the order they were registered
+func Types() []*Type {
+	return append([]*Type{}, types...)
+}
+
+// Lookup returns the resource type with the given name
+func Lookup(name string) (*Type, bool) {
+	t, ok := byName[name]
+	return t, ok
+}
+
+// Catalog returns the resources catalog entry of every registered type
+func Catalog() model.Resources {
+	resources := model.Resources{}
+	for _, t := range types {
+		resources = append(resources, model.Resource{ID: t.CatalogID, Name: t.Title, Type: t.Name, Available: true})
+	}
+	return resources
+}
+
+// Scope returns the scope granting an action on resources of the type
+func (t *Type) Scope(action string) string {
+	return t.Table + ":" + action
+}
+
+// Columns returns the columns of the fields specific to the type
+func (t *Type) Columns() []string {
+	columns := []string{}
+	for _, f := range t.Fields {
+		columns = append(columns, f.Name)
+	}
+	return columns
+}
+
+// CapacityExpr returns the SQL expression giving how much of the capacity quota a resource uses
+func (t *Type) CapacityExpr() string {
+	return strings.Join(t.Capacity, " * ")
+}
+
+// RowCapacity returns how much of the capacity quota a row uses
+func (t *Type) RowCapacity(row interface{}) int {
+	v := reflect.ValueOf(row)
+	capacity := 1
+	for _, name := range t.Capacity {
+		capacity *= int(v.FieldByName(goName(name)).Int())
+	}
+	return capacity
+}
+
+// NewRow returns a pointer to an empty row of the type
+func (t *Type) NewRow() interface{} {
+	return reflect.New(t.row).Interface()
+}
+
+// NewBody returns a pointer to an empty request body of the type
+func (t *Type) NewBody() interface{} {
+	return reflect.New(t.body).Interface()
+}
+
+// RowFromBody returns a row holding the name, fields and tags of a request body
+func (t *Type) RowFromBody(body interface{}) interface{} {
+	return copyFields(reflect.New(t.row).Elem(), reflect.Indirect(reflect.ValueOf(body)))
+}
+
+// BodyFromRow returns a request body holding the name, fields and tags of a row.
+// Secret fields are left empty, as they are never returned.
+func (t *Type) BodyFromRow(row interface{}) interface{} {
+	v := reflect.New(t.body).Elem()
+	copyFields(v, reflect.Indirect(reflect.ValueOf(row)))
+	for _, f := range t.Fields {
+		if f.Secret {
+			field := v.FieldByName(goName(f.Name))
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+	return v.Interface()
+}
+
+// Secrets returns the struct field names of the secret fields, so they can be excluded from validating request bodies
+// which can't set them
+func (t *Type) Secrets() []string {
+	secrets := []string{}
+	for _, f := range t.Fields {
+		if f.Secret {
+			secrets = append(secrets, goName(f.Name))
+		}
+	}
+	return secrets
+}
+
+// ID returns the id of a row
+func ID(row interface{}) string {
+	return reflect.Indirect(reflect.ValueOf(row)).FieldByName("ID").String()
+}
+
+// Version returns the version of a row
+func Version(row interface{}) int {
+	return int(reflect.Indirect(reflect.ValueOf(row)).FieldByName("Version").Int())
+}
+
+// Status returns the status of a row
+func Status(row interface{}) string {
+	return reflect.Indirect(reflect.ValueOf(row)).FieldByName("Status").String()
+}
+
+// WithOrganization returns a copy of a row belonging to the organization, or to no organization if orgID is nil
+func WithOrganization(row interface{}, orgID *string) interface{} {
+	v := reflect.New(reflect.TypeOf(row)).Elem()
+	v.Set(reflect.ValueOf(row))
+	v.FieldByName("OrganizationID").Set(reflect.ValueOf(orgID))
+	return v.Interface()
+}
+
+// DefaultTags gives a request body, which must be a pointer, empty tags if it has none
+func DefaultTags(body interface{}) {
+	tags := reflect.ValueOf(body).Elem().FieldByName("Tags")
+	if tags.IsNil() {
+		tags.Set(reflect.ValueOf(model.Tags{}))
+	}
+}
+
+// copyFields sets the fields of dst which src also has to their values in src, returning dst
+func copyFields(dst reflect.Value, src reflect.Value) interface{} {
+	for i := 0; i < dst.NumField(); i++ {
+		if value := src.FieldByName(dst.Type().Field(i).Name); value.IsValid() {
+			dst.Field(i).Set(value)
+		}
+	}
+	return dst.Interface()
+}
+
+// rowType builds the struct rows of a type are held in, with the columns every resource has around its own fields
+func rowType(fields []Field) reflect.Type {
+	structFields := []reflect.StructField{
+		column("ID", "id", reflect.TypeOf(""), `json:"id,omitempty"`),
+		column("UserID", "user_id", reflect.TypeOf(""), `json:"-"`),
+		column("OrganizationID", "organization_id", reflect.TypeOf((*string)(nil)), `json:"organization_id,omitempty"`),
+		column("Name", "name", reflect.TypeOf(""), `json:"name"`),
+	}
+	for _, f := range fields {
+		json := fmt.Sprintf(`json:"%s"`, f.Name)
+		if f.Secret {
+			json = `json:"-"`
+		}
+		structFields = append(structFields, column(goName(f.Name), f.Name, f.Kind.goType(), json))
+	}
+	structFields = append(structFields,
+		column("Tags", "tags", reflect.TypeOf(model.Tags{}), `json:"tags"`),
+		column("Status", "status", reflect.TypeOf(""), `json:"status"`),
+		column("Version", "version", reflect.TypeOf(0), `json:"-"`),
+		column("CreatedAt", "created_at", reflect.TypeOf(time.Time{}), `json:"created_at"`),
+		column("UpdatedAt", "updated_at", reflect.TypeOf(time.Time{}), `json:"updated_at"`),
+		column("DeletedAt", "deleted_at", reflect.TypeOf(sql.NullString{}), `json:"-"`),
+	)
+	return reflect.StructOf(structFields)
+}
+
+// bodyType builds the struct requests creating or changing resources of a type are decoded into
+func bodyType(fields []Field) reflect.Type {
+	structFields := []reflect.StructField{
+		{Name: "Name", Type: reflect.TypeOf(""), Tag: reflect.StructTag(fmt.Sprintf(`json:"name" validate:"%s"`, nameRules))},
+	}
+	for _, f := range fields {
+		json := fmt.Sprintf(`json:"%s"`, f.Name)
+		if f.Secret {
+			json = `json:"-"`
+		}
+		structFields = append(structFields, reflect.StructField{
+			Name: goName(f.Name),
+			Type: f.Kind.goType(),
+			Tag:  reflect.StructTag(fmt.Sprintf(`%s validate:"%s"`, json, f.Validate)),
+		})
+	}
+	structFields = append(structFields, reflect.StructField{
+		Name: "Tags", Type: reflect.TypeOf(model.Tags{}), Tag: `json:"tags" validate:"tags"`,
+	})
+	return reflect.StructOf(structFields)
+}
+
+func column(name string, db string, t reflect.Type, json string) reflect.StructField {
+	return reflect.StructField{Name: name, Type: t, Tag: reflect.StructTag(fmt.Sprintf(`db:"%s" %s`, db, json))}
+}
+
+func (k Kind) goType() reflect.Type {
+	if k == Int {
+		return reflect.TypeOf(0)
+	}
+	return reflect.TypeOf("")
+}
+
+// goName converts a snake case field name to the exported struct field holding it
+func goName(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,40 @@
+package registry
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	resources := Catalog()
+	if len(resources) != len(Types()) {
+		t.Fatalf("catalog has %d resources, want %d", len(resources), len(Types()))
+	}
+
+	for i, r := range resources {
+		if r.Type != Types()[i].Name || !r.Available {
+			t.Errorf("catalog entry %d = %+v, want available %s", i, r, Types()[i].Name)
+		}
+	}
+}
+
+func TestRowFromBody(t *testing.T) {
+	body := SQLDatabase.NewBody()
+	err := json.Unmarshal([]byte(`{"name": "orders", "username": "admin", "password": "s3cret", "quantity": 2}`), body)
+	if err != nil {
+		t.Fatalf("Unable to decode body: %s", err)
+	}
+
+	row := SQLDatabase.RowFromBody(body)
+	if SQLDatabase.RowCapacity(row) != 2 {
+		t.Errorf("capacity = %d, want 2", SQLDatabase.RowCapacity(row))
+	}
+	if password := reflect.ValueOf(row).FieldByName("Password").String(); password != "" {
+		t.Errorf("secret password set to %q from the request body", password)
+	}
+
+	if secrets := SQLDatabase.Secrets(); len(secrets) != 1 || secrets[0] != "Password" {
+		t.Errorf("secrets = %v, want [Password]", secrets)
+	}
+}
@@ -0,0 +1,68 @@
+package registry
+
+// Lambda is a temporary server which does its business then cleans up after itself... proper neat!
+var Lambda = Register(Type{
+	Name:      "lambda",
+	Title:     "Serverless Lambda Function",
+	CatalogID: "resource-001",
+	Label:     "lambda",
+	Path:      "/lambdas",
+	Table:     "lambdas",
+	Fields: []Field{
+		{Name: "concurrent_limit", Kind: Int, Validate: "required,gte=1,lte=200"},
+	},
+	Capacity:     []string{"concurrent_limit"},
+	CapacityUnit: "concurrent_limit",
+	DefaultQuota: Quota{MaxCount: 50, MaxCapacity: 1000},
+})
+
+// VirtualMachine is like running on a real computer... except its not!
+var VirtualMachine = Register(Type{
+	Name:      "virtual_machine",
+	Title:     "Virtual Machine",
+	CatalogID: "resource-002",
+	Label:     "virtual machine",
+	Path:      "/virtual-machines",
+	Table:     "virtual_machines",
+	Fields: []Field{
+		{Name: "cpus", Kind: Int, Validate: "required,gte=1,lte=64"},
+		{Name: "quantity", Kind: Int, Validate: "required,gte=1,lte=500"},
+	},
+	Capacity:     []string{"cpus", "quantity"},
+	CapacityUnit: "cpus",
+	DefaultQuota: Quota{MaxCount: 20, MaxCapacity: 256},
+})
+
+// SQLDatabase is a old-school DB boi
+var SQLDatabase = Register(Type{
+	Name:      "sql_database",
+	Title:     "SQL Database",
+	CatalogID: "resource-003",
+	Label:     "SQL database",
+	Path:      "/sql-databases",
+	Table:     "sql_databases",
+	Fields: []Field{
+		{Name: "username", Kind: String, Validate: "required,min=5,max=50"},
+		{Name: "password", Kind: String, Validate: "required,min=8,max=200", Secret: true},
+		{Name: "quantity", Kind: Int, Validate: "required,gte=1,lte=50"},
+	},
+	Capacity:     []string{"quantity"},
+	CapacityUnit: "quantity",
+	DefaultQuota: Quota{MaxCount: 10, MaxCapacity: 50},
+})
+
+// NoSQLDatabase is an edgy new way to store data
+var NoSQLDatabase = Register(Type{
+	Name:      "nosql_database",
+	Title:     "No-SQL Database",
+	CatalogID: "resource-004",
+	Label:     "NoSQL database",
+	Path:      "/nosql-databases",
+	Table:     "nosql_databases",
+	Fields: []Field{
+		{Name: "shards", Kind: Int, Validate: "required,gte=1,lte=50"},
+	},
+	Capacity:     []string{"shards"},
+	CapacityUnit: "shards",
+	DefaultQuota: Quota{MaxCount: 10, MaxCapacity: 200},
+})
@@ -0,0 +1,111 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+const (
+	// defaultMinPasswordLength is the shortest password allowed when min_length is not set
+	defaultMinPasswordLength = 8
+	// maxPasswordBytes is the longest password allowed, as bcrypt ignores anything after it
+	maxPasswordBytes = 72
+)
+
+// usernamePattern matches the characters usernames may contain
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]*$`)
+
+// PasswordPolicy contains the rules new passwords must follow
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`
+	MaxLength     int  `json:"max_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+}
+
+// minLength returns the shortest password allowed
+func (p *PasswordPolicy) minLength() int {
+	if p.MinLength <= 0 {
+		return defaultMinPasswordLength
+	}
+	return p.MinLength
+}
+
+// maxLength returns the longest password allowed, which is never more than bcrypt can hash
+func (p *PasswordPolicy) maxLength() int {
+	if p.MaxLength <= 0 || p.MaxLength > maxPasswordBytes {
+		return maxPasswordBytes
+	}
+	return p.MaxLength
+}
+
+// Allows reports whether a password follows every rule of the policy
+func (p *PasswordPolicy) Allows(password string) bool {
+	length := utf8.RuneCountInString(password)
+	if length < p.minLength() || length > p.maxLength() || len(password) > maxPasswordBytes {
+		return false
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	return (upper || !p.RequireUpper) && (lower || !p.RequireLower) &&
+		(digit || !p.RequireDigit) && (symbol || !p.RequireSymbol)
+}
+
+// Rules describes the policy, for telling users what their password needs
+func (p *PasswordPolicy) Rules() string {
+	rules := []string{fmt.Sprintf("be %d to %d characters long", p.minLength(), p.maxLength())}
+
+	contains := []string{}
+	if p.RequireUpper {
+		contains = append(contains, "an uppercase letter")
+	}
+	if p.RequireLower {
+		contains = append(contains, "a lowercase letter")
+	}
+	if p.RequireDigit {
+		contains = append(contains, "a digit")
+	}
+	if p.RequireSymbol {
+		contains = append(contains, "a symbol")
+	}
+	if n := len(contains); n > 1 {
+		rules = append(rules, "contain "+strings.Join(contains[:n-1], ", ")+" and "+contains[n-1])
+	} else if n == 1 {
+		rules = append(rules, "contain "+contains[0])
+	}
+
+	return strings.Join(rules, " and ")
+}
+
+// isPassword validates fields tagged password against the policy
+func isPassword(passwords func() PasswordPolicy) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		policy := passwords()
+		return policy.Allows(fl.Field().String())
+	}
+}
+
+// isUsername validates fields tagged username only contain letters, digits, '.', '_' and '-'
+func isUsername(fl validator.FieldLevel) bool {
+	return usernamePattern.MatchString(fl.Field().String())
+}
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"reflect"
+	"regexp"
+	"unicode/utf8"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+const (
+	// maxTags is the most tags a resource can have
+	maxTags = 50
+	// maxTagKeyLength is the longest a tag key can be
+	maxTagKeyLength = 128
+	// maxTagValueLength is the longest a tag value can be
+	maxTagValueLength = 256
+)
+
+// tagKeyPattern matches the characters tag keys may contain. Colons are left out as they separate keys from
+// values in tag filters.
+var tagKeyPattern = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+
+// isTags validates maps of tags tagged tags have no more than maxTags entries with valid keys and values
+func isTags(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.Map || field.Len() > maxTags {
+		return false
+	}
+
+	iter := field.MapRange()
+	for iter.Next() {
+		key, value := iter.Key().String(), iter.Value().String()
+		if utf8.RuneCountInString(key) > maxTagKeyLength || !tagKeyPattern.MatchString(key) {
+			return false
+		}
+		if utf8.RuneCountInString(value) > maxTagValueLength {
+			return false
+		}
+	}
+
+	return true
+}
@@ -3,9 +3,11 @@ package validation
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/danielpadmore/cloudygo-service/logs"
+	"github.com/danielpadmore/cloudygo-service/problem"
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"gopkg.in/go-playground/validator.v9"
@@ -18,8 +20,9 @@ type Validator struct {
 	trans    ut.Translator
 }
 
-// New registers custom validations and creates a validator
-func New(logger logs.Logger) Validator {
+// New registers custom validations and creates a validator. Passwords are checked against the policy returned by
+// passwords when they are validated.
+func New(logger logs.Logger, passwords func() PasswordPolicy) Validator {
 	translator := en.New()
 	uni := ut.New(translator, translator)
 
@@ -41,6 +44,31 @@ func New(logger logs.Logger) Validator {
 		return t
 	})
 
+	_ = val.RegisterValidation("password", isPassword(passwords))
+	_ = val.RegisterTranslation("password", trans, func(ut ut.Translator) error {
+		return ut.Add("password", "{0} must {1}", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		policy := passwords()
+		t, _ := ut.T("password", fe.Field(), policy.Rules())
+		return t
+	})
+
+	_ = val.RegisterValidation("username", isUsername)
+	_ = val.RegisterTranslation("username", trans, func(ut ut.Translator) error {
+		return ut.Add("username", "{0} can only contain letters, digits, '.', '_' and '-'", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("username", fe.Field())
+		return t
+	})
+
+	_ = val.RegisterValidation("tags", isTags)
+	_ = val.RegisterTranslation("tags", trans, func(ut ut.Translator) error {
+		return ut.Add("tags", "{0} can have up to {1} tags, with keys of 1 to {2} letters, digits, '.', '_', '-' or '/' and values of up to {3} characters", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("tags", fe.Field(), strconv.Itoa(maxTags), strconv.Itoa(maxTagKeyLength), strconv.Itoa(maxTagValueLength))
+		return t
+	})
+
 	val.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
 		if name == "-" {
@@ -61,3 +89,13 @@ func (val *Validator) ConcatReasons(err error) string {
 	}
 	return strings.Join(reasons, ", ")
 }
+
+// FieldErrors returns the validation reason of each invalid field
+func (val *Validator) FieldErrors(err error) []problem.FieldError {
+	valErrs := err.(validator.ValidationErrors)
+	fields := make([]problem.FieldError, 0, len(valErrs))
+	for _, e := range valErrs {
+		fields = append(fields, problem.FieldError{Field: e.Field(), Rule: e.Tag(), Message: e.Translate(val.trans)})
+	}
+	return fields
+}